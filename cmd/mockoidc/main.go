@@ -0,0 +1,33 @@
+// mockoidc é um IdP OpenID Connect de mentira pra testar o login OIDC localmente.
+// Aprova qualquer login na hora: o e-mail vem de ?login_hint= (padrão hunter@example.com).
+//
+//	go run ./cmd/mockoidc
+//	OIDC_ISSUER=http://localhost:9000 OIDC_CLIENT_ID=solo go run ./cmd/api
+package main
+
+import (
+	"log"
+	"net/http"
+	"os"
+
+	"github.com/gabrieldemesio/solo-leveling-go-mvp-v2/internal/auth/oidc/oidctest"
+)
+
+func getenv(k, def string) string {
+	if v := os.Getenv(k); v != "" {
+		return v
+	}
+	return def
+}
+
+func main() {
+	addr := getenv("MOCK_OIDC_ADDR", ":9000")
+	issuer := getenv("MOCK_OIDC_ISSUER", "http://localhost:9000")
+
+	iss, err := oidctest.New(issuer, getenv("MOCK_OIDC_EMAIL", "hunter@example.com"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Println("mock OIDC issuer", issuer, "listening on", addr)
+	log.Fatal(http.ListenAndServe(addr, iss))
+}
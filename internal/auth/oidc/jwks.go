@@ -0,0 +1,109 @@
+package oidc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+)
+
+// jwkCache guarda as chaves públicas do IdP e busca de novo quando aparece um kid desconhecido
+// (o IdP rotacionou), no máximo uma vez por minuto.
+type jwkCache struct {
+	uri   string
+	fetch func(ctx context.Context, u string, v any) error
+
+	mu        sync.Mutex
+	keys      map[string]jwk
+	fetchedAt time.Time
+}
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func (c *jwkCache) key(ctx context.Context, kid, alg string) (any, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	k, ok := c.keys[kid]
+	if !ok && time.Since(c.fetchedAt) > time.Minute {
+		var set struct {
+			Keys []jwk `json:"keys"`
+		}
+		if err := c.fetch(ctx, c.uri, &set); err != nil {
+			return nil, err
+		}
+		c.keys = make(map[string]jwk, len(set.Keys))
+		for _, k := range set.Keys {
+			c.keys[k.Kid] = k
+		}
+		c.fetchedAt = time.Now()
+		k, ok = c.keys[kid]
+	}
+	if !ok {
+		return nil, fmt.Errorf("unknown kid %q", kid)
+	}
+	if k.Alg != "" && k.Alg != alg {
+		return nil, fmt.Errorf("kid %q is not a %s key", kid, alg)
+	}
+	return k.publicKey(alg)
+}
+
+func (k jwk) publicKey(alg string) (any, error) {
+	switch {
+	case k.Kty == "RSA" && alg == "RS256":
+		n, err := b64Int(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := b64Int(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case k.Kty == "EC" && k.Crv == "P-256" && alg == "ES256":
+		x, err := b64Int(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := b64Int(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		if !elliptic.P256().IsOnCurve(x, y) {
+			return nil, errors.New("EC point is not on P-256")
+		}
+		return &ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}, nil
+	case k.Kty == "OKP" && k.Crv == "Ed25519" && alg == "EdDSA":
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid Ed25519 key")
+		}
+		return ed25519.PublicKey(x), nil
+	}
+	return nil, fmt.Errorf("unsupported key kty=%s crv=%s for %s", k.Kty, k.Crv, alg)
+}
+
+func b64Int(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(b), nil
+}
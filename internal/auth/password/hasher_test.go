@@ -0,0 +1,113 @@
+package password
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// parâmetros baixos só pra o teste não gastar 19 MiB por hash
+var testArgon2id = Argon2id{Memory: 64, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+
+func TestArgon2idHashAndVerify(t *testing.T) {
+	enc, err := testArgon2id.Hash("correct horse battery staple")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(enc, "$argon2id$v=19$m=64,t=1,p=1$") {
+		t.Fatalf("unexpected encoding %q", enc)
+	}
+	if ok, err := testArgon2id.Verify("correct horse battery staple", enc); err != nil || !ok {
+		t.Fatalf("Verify(right) = %v, %v", ok, err)
+	}
+	if ok, err := testArgon2id.Verify("wrong", enc); err != nil || ok {
+		t.Fatalf("Verify(wrong) = %v, %v", ok, err)
+	}
+	other, err := testArgon2id.Hash("correct horse battery staple")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other == enc {
+		t.Fatal("same salt used twice")
+	}
+}
+
+func TestArgon2idVerifyUsesStoredParams(t *testing.T) {
+	// hash gravado com parâmetros antigos continua valendo depois de mudar os atuais
+	enc, err := testArgon2id.Hash("s3cret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	current := Argon2id{Memory: 128, Iterations: 2, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+	if ok, err := current.Verify("s3cret", enc); err != nil || !ok {
+		t.Fatalf("Verify = %v, %v", ok, err)
+	}
+}
+
+func TestVerifyLegacyBcrypt(t *testing.T) {
+	b, err := bcrypt.GenerateFromPassword([]byte("legacy-pass"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	enc := string(b)
+	if ok, err := testArgon2id.Verify("legacy-pass", enc); err != nil || !ok {
+		t.Fatalf("Verify(right) = %v, %v", ok, err)
+	}
+	if ok, err := testArgon2id.Verify("nope", enc); err != nil || ok {
+		t.Fatalf("Verify(wrong) = %v, %v", ok, err)
+	}
+	for _, prefix := range []string{"$2a$", "$2y$"} {
+		if !isBcrypt(prefix + strings.TrimPrefix(enc, "$2a$")) {
+			t.Errorf("%s not recognized as bcrypt", prefix)
+		}
+	}
+}
+
+func TestVerifyUnknownFormat(t *testing.T) {
+	for _, enc := range []string{
+		"",
+		"plaintext",
+		"$argon2i$v=19$m=64,t=1,p=1$c2FsdA$a2V5",
+		"$argon2id$v=16$m=64,t=1,p=1$c2FsdA$a2V5",
+		"$argon2id$v=19$m=64,t=1,p=1$!!$a2V5",
+		"$argon2id$v=19$m=64,t=1,p=1$c2FsdA$",
+	} {
+		if _, err := testArgon2id.Verify("x", enc); err != ErrUnknownHash {
+			t.Errorf("Verify(%q) err = %v, want ErrUnknownHash", enc, err)
+		}
+	}
+}
+
+func TestNeedsRehash(t *testing.T) {
+	enc, err := testArgon2id.Hash("pw")
+	if err != nil {
+		t.Fatal(err)
+	}
+	bc, err := bcrypt.GenerateFromPassword([]byte("pw"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		name   string
+		hasher Argon2id
+		enc    string
+		want   bool
+	}{
+		{"same params", testArgon2id, enc, false},
+		{"more memory", Argon2id{Memory: 128, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}, enc, true},
+		{"more iterations", Argon2id{Memory: 64, Iterations: 2, Parallelism: 1, SaltLength: 16, KeyLength: 32}, enc, true},
+		{"more parallelism", Argon2id{Memory: 64, Iterations: 1, Parallelism: 2, SaltLength: 16, KeyLength: 32}, enc, true},
+		{"longer salt", Argon2id{Memory: 64, Iterations: 1, Parallelism: 1, SaltLength: 32, KeyLength: 32}, enc, true},
+		{"longer key", Argon2id{Memory: 64, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 64}, enc, true},
+		{"legacy bcrypt", testArgon2id, string(bc), true},
+		{"garbage", testArgon2id, "garbage", true},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := c.hasher.NeedsRehash(c.enc); got != c.want {
+				t.Fatalf("NeedsRehash = %v, want %v", got, c.want)
+			}
+		})
+	}
+}
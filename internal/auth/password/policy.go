@@ -0,0 +1,81 @@
+package password
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxLength evita que alguém mande megabytes de senha só pra queimar CPU no hash.
+const maxLength = 1024
+
+var (
+	ErrTooLong  = errors.New("password is too long")
+	ErrBreached = errors.New("password appears in a list of breached passwords")
+)
+
+type Policy struct {
+	MinLength int
+	// breached guarda SHA-1 em hex maiúsculo, o mesmo formato das listas do HIBP.
+	breached map[string]struct{}
+}
+
+// LoadBreachedList lê um arquivo com uma senha vazada por linha. Linhas que já são SHA-1 em hex
+// (opcionalmente no formato "HASH:contagem" do HIBP) entram como estão; o resto é tratado como texto puro.
+func (p *Policy) LoadBreachedList(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	set := make(map[string]struct{})
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" {
+			continue
+		}
+		if h, _, _ := strings.Cut(line, ":"); isSHA1Hex(h) {
+			set[strings.ToUpper(h)] = struct{}{}
+			continue
+		}
+		set[sha1Hex(line)] = struct{}{}
+	}
+	if err := sc.Err(); err != nil {
+		return err
+	}
+	p.breached = set
+	return nil
+}
+
+func (p *Policy) Check(password string) error {
+	if n := utf8.RuneCountInString(password); n < p.MinLength {
+		return fmt.Errorf("password must have at least %d characters", p.MinLength)
+	}
+	if len(password) > maxLength {
+		return ErrTooLong
+	}
+	if _, found := p.breached[sha1Hex(password)]; found {
+		return ErrBreached
+	}
+	return nil
+}
+
+func sha1Hex(s string) string {
+	sum := sha1.Sum([]byte(s))
+	return strings.ToUpper(hex.EncodeToString(sum[:]))
+}
+
+func isSHA1Hex(s string) bool {
+	if len(s) != 40 {
+		return false
+	}
+	_, err := hex.DecodeString(s)
+	return err == nil
+}
@@ -0,0 +1,44 @@
+package signing
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"math/big"
+)
+
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+}
+
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS publica as chaves públicas ainda não aposentadas, inclusive as que vão entrar em uso
+// (assim quem verifica já tem a chave antes do primeiro token assinado com ela).
+func (s *KeySet) JWKS() JWKSet {
+	set := JWKSet{Keys: []JWK{}}
+	for i, k := range s.keys {
+		if s.retired(i) {
+			continue
+		}
+		switch pub := k.verifyKey.(type) {
+		case ed25519.PublicKey:
+			set.Keys = append(set.Keys, JWK{Kty: "OKP", Kid: k.ID, Alg: k.Algorithm, Use: "sig", Crv: "Ed25519",
+				X: base64.RawURLEncoding.EncodeToString(pub)})
+		case *rsa.PublicKey:
+			set.Keys = append(set.Keys, JWK{Kty: "RSA", Kid: k.ID, Alg: k.Algorithm, Use: "sig",
+				N: base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+				E: base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())})
+		}
+	}
+	return set
+}
@@ -0,0 +1,158 @@
+// Package signing guarda as chaves que assinam e verificam os JWTs emitidos pela API.
+//
+// Cada chave tem um kid e um instante a partir do qual passa a assinar (ActiveFrom). Rotação:
+// adiciona a chave nova no manifesto com ActiveFrom no futuro; ela já sai no JWKS antes de assinar
+// qualquer coisa, e a chave anterior continua aceita na verificação por Overlap depois da troca.
+//
+// Chaves privadas são PEM PKCS#8 (ex.: openssl genpkey -algorithm ed25519 -out 2026-10.pem).
+package signing
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Audiences dos tokens emitidos pela API. As mesmas chaves assinam todos os tipos, então cada um
+// leva o próprio aud e quem verifica exige o seu: um link de verificação não serve como access token.
+const (
+	AudienceAccess      = "solo-leveling:access"
+	AudienceEmailVerify = "solo-leveling:email-verify"
+	AudienceMFA         = "solo-leveling:mfa"
+)
+
+var (
+	ErrNoSigningKey = errors.New("no active signing key")
+	ErrUnknownKey   = errors.New("unknown or retired signing key")
+)
+
+type Key struct {
+	ID         string
+	Algorithm  string // EdDSA, RS256 ou HS256
+	ActiveFrom time.Time
+
+	signKey   any // ed25519.PrivateKey, *rsa.PrivateKey ou []byte
+	verifyKey any // ed25519.PublicKey, *rsa.PublicKey ou []byte
+}
+
+func NewKey(id string, activeFrom time.Time, private crypto.PrivateKey) (*Key, error) {
+	switch k := private.(type) {
+	case ed25519.PrivateKey:
+		return &Key{ID: id, Algorithm: jwt.SigningMethodEdDSA.Alg(), ActiveFrom: activeFrom, signKey: k, verifyKey: k.Public()}, nil
+	case *rsa.PrivateKey:
+		if k.N.BitLen() < 2048 {
+			return nil, fmt.Errorf("key %s: RSA keys must have at least 2048 bits", id)
+		}
+		return &Key{ID: id, Algorithm: jwt.SigningMethodRS256.Alg(), ActiveFrom: activeFrom, signKey: k, verifyKey: &k.PublicKey}, nil
+	default:
+		return nil, fmt.Errorf("key %s: unsupported private key type %T", id, private)
+	}
+}
+
+// NewHMACKey é o modo legado (JWT_SECRET compartilhado). Não aparece no JWKS.
+func NewHMACKey(id string, secret []byte) *Key {
+	return &Key{ID: id, Algorithm: jwt.SigningMethodHS256.Alg(), signKey: secret, verifyKey: secret}
+}
+
+func (k *Key) method() jwt.SigningMethod {
+	return jwt.GetSigningMethod(k.Algorithm)
+}
+
+type KeySet struct {
+	// keys ordenadas por ActiveFrom
+	keys    []*Key
+	overlap time.Duration
+	now     func() time.Time
+}
+
+func NewKeySet(overlap time.Duration, keys ...*Key) (*KeySet, error) {
+	if len(keys) == 0 {
+		return nil, ErrNoSigningKey
+	}
+	seen := map[string]bool{}
+	for _, k := range keys {
+		if k.ID == "" || seen[k.ID] {
+			return nil, fmt.Errorf("duplicate or empty kid %q", k.ID)
+		}
+		seen[k.ID] = true
+	}
+	sorted := append([]*Key(nil), keys...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].ActiveFrom.Before(sorted[j].ActiveFrom) })
+	return &KeySet{keys: sorted, overlap: overlap, now: time.Now}, nil
+}
+
+// signingKey é a chave mais recente que já está ativa.
+func (s *KeySet) signingKey() (*Key, error) {
+	now := s.now()
+	var cur *Key
+	for _, k := range s.keys {
+		if k.ActiveFrom.After(now) {
+			break
+		}
+		cur = k
+	}
+	if cur == nil {
+		return nil, ErrNoSigningKey
+	}
+	return cur, nil
+}
+
+// retired diz se a chave i já foi substituída há mais de overlap.
+func (s *KeySet) retired(i int) bool {
+	if i+1 >= len(s.keys) {
+		return false
+	}
+	next := s.keys[i+1]
+	return s.now().After(next.ActiveFrom.Add(s.overlap))
+}
+
+func (s *KeySet) lookup(kid string) (*Key, bool) {
+	for i, k := range s.keys {
+		if k.ID == kid {
+			return k, !s.retired(i)
+		}
+	}
+	return nil, false
+}
+
+func (s *KeySet) Sign(claims jwt.Claims) (string, error) {
+	k, err := s.signingKey()
+	if err != nil {
+		return "", err
+	}
+	t := jwt.NewWithClaims(k.method(), claims)
+	t.Header["kid"] = k.ID
+	return t.SignedString(k.signKey)
+}
+
+// Parse valida assinatura e claims registradas. O alg do header precisa ser exatamente o da chave
+// apontada pelo kid, então não tem como trocar RS256/EdDSA por HS256 nem por "none".
+func (s *KeySet) Parse(tokenStr string, claims jwt.Claims, opts ...jwt.ParserOption) error {
+	methods := map[string]bool{}
+	for _, k := range s.keys {
+		methods[k.Algorithm] = true
+	}
+	valid := make([]string, 0, len(methods))
+	for m := range methods {
+		valid = append(valid, m)
+	}
+	opts = append(opts, jwt.WithValidMethods(valid), jwt.WithExpirationRequired(), jwt.WithTimeFunc(s.now))
+	_, err := jwt.ParseWithClaims(tokenStr, claims, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		k, ok := s.lookup(kid)
+		if !ok {
+			return nil, ErrUnknownKey
+		}
+		if t.Method.Alg() != k.Algorithm {
+			return nil, fmt.Errorf("unexpected signing method %s for key %s", t.Method.Alg(), kid)
+		}
+		return k.verifyKey, nil
+	}, opts...)
+	return err
+}
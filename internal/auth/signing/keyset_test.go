@@ -0,0 +1,53 @@
+package signing
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestParseEnforcesAudience(t *testing.T) {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	k, err := NewKey("k1", time.Now().Add(-time.Hour), priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ks, err := NewKeySet(time.Hour, k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := time.Now().Add(time.Minute).Unix()
+	verify, err := ks.Sign(jwt.MapClaims{"iss": "solo-leveling", "aud": AudienceEmailVerify, "exp": exp})
+	if err != nil {
+		t.Fatal(err)
+	}
+	noAud, err := ks.Sign(jwt.MapClaims{"iss": "solo-leveling", "exp": exp})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		name  string
+		token string
+		aud   string
+		ok    bool
+	}{
+		{"matching audience", verify, AudienceEmailVerify, true},
+		{"email token as access", verify, AudienceAccess, false},
+		{"email token as mfa", verify, AudienceMFA, false},
+		{"token without audience", noAud, AudienceAccess, false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := ks.Parse(c.token, jwt.MapClaims{}, jwt.WithIssuer("solo-leveling"), jwt.WithAudience(c.aud))
+			if (err == nil) != c.ok {
+				t.Fatalf("Parse err = %v, want ok=%v", err, c.ok)
+			}
+		})
+	}
+}
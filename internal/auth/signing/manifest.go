@@ -0,0 +1,80 @@
+package signing
+
+import (
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// manifest é o arquivo apontado por JWT_KEYS_FILE, por exemplo:
+//
+//	{
+//	  "overlap": "48h",
+//	  "keys": [
+//	    {"kid": "2026-09", "privateKeyFile": "2026-09.pem", "activeFrom": "2026-09-01T00:00:00Z"},
+//	    {"kid": "2026-10", "privateKeyFile": "2026-10.pem", "activeFrom": "2026-10-01T00:00:00Z"}
+//	  ]
+//	}
+//
+// Caminhos relativos são resolvidos a partir do diretório do manifesto.
+type manifest struct {
+	Overlap string `json:"overlap"`
+	Keys    []struct {
+		Kid            string    `json:"kid"`
+		PrivateKeyFile string    `json:"privateKeyFile"`
+		ActiveFrom     time.Time `json:"activeFrom"`
+	} `json:"keys"`
+}
+
+func LoadManifest(path string) (*KeySet, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var m manifest
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	overlap := 48 * time.Hour
+	if m.Overlap != "" {
+		if overlap, err = time.ParseDuration(m.Overlap); err != nil {
+			return nil, fmt.Errorf("%s: overlap: %w", path, err)
+		}
+	}
+	keys := make([]*Key, 0, len(m.Keys))
+	for _, mk := range m.Keys {
+		file := mk.PrivateKeyFile
+		if !filepath.IsAbs(file) {
+			file = filepath.Join(filepath.Dir(path), file)
+		}
+		priv, err := readPrivateKey(file)
+		if err != nil {
+			return nil, fmt.Errorf("key %s: %w", mk.Kid, err)
+		}
+		k, err := NewKey(mk.Kid, mk.ActiveFrom, priv)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, k)
+	}
+	return NewKeySet(overlap, keys...)
+}
+
+func readPrivateKey(path string) (any, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(b)
+	if block == nil {
+		return nil, fmt.Errorf("%s: no PEM block", path)
+	}
+	if block.Type == "RSA PRIVATE KEY" {
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+	}
+	return x509.ParsePKCS8PrivateKey(block.Bytes)
+}
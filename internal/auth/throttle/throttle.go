@@ -0,0 +1,66 @@
+package throttle
+
+import "time"
+
+// Policy define quanto tempo uma chave (conta ou IP) fica bloqueada depois de n falhas seguidas de login.
+type Policy struct {
+	// FreeAttempts falhas passam sem atraso nenhum.
+	FreeAttempts int
+	// BaseDelay é o atraso após a primeira falha além das livres; dobra a cada nova falha até MaxDelay.
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// LockoutAfter falhas bloqueiam a conta por LockoutFor (0 desliga o lockout).
+	LockoutAfter int
+	LockoutFor   time.Duration
+	// Window é depois de quanto tempo sem falhas o contador zera.
+	Window time.Duration
+}
+
+var (
+	Account = Policy{
+		FreeAttempts: 3,
+		BaseDelay:    time.Second,
+		MaxDelay:     time.Minute,
+		LockoutAfter: 10,
+		LockoutFor:   15 * time.Minute,
+		Window:       time.Hour,
+	}
+	IP = Policy{
+		FreeAttempts: 10,
+		BaseDelay:    time.Second,
+		MaxDelay:     15 * time.Minute,
+		Window:       time.Hour,
+	}
+	// PasswordReset conta todo pedido de reset, não só falhas: cada um manda um e-mail pra conta.
+	PasswordReset = Policy{
+		FreeAttempts: 3,
+		BaseDelay:    time.Minute,
+		MaxDelay:     time.Hour,
+		Window:       time.Hour,
+	}
+	PasswordResetIP = Policy{
+		FreeAttempts: 10,
+		BaseDelay:    time.Minute,
+		MaxDelay:     time.Hour,
+		Window:       time.Hour,
+	}
+)
+
+// Decide devolve o bloqueio que a n-ésima falha seguida gera e se ele é um lockout.
+func (p Policy) Decide(failures int) (time.Duration, bool) {
+	if p.LockoutAfter > 0 && failures >= p.LockoutAfter {
+		return p.LockoutFor, true
+	}
+	over := failures - p.FreeAttempts
+	if over <= 0 {
+		return 0, false
+	}
+	d := p.BaseDelay
+	for i := 1; i < over && d < p.MaxDelay; i++ {
+		d *= 2
+	}
+	if d > p.MaxDelay {
+		d = p.MaxDelay
+	}
+	return d, false
+}
@@ -0,0 +1,86 @@
+package throttle
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDecideAccount(t *testing.T) {
+	cases := []struct {
+		failures int
+		delay    time.Duration
+		lockout  bool
+	}{
+		{0, 0, false},
+		{1, 0, false},
+		{3, 0, false},
+		{4, time.Second, false},
+		{5, 2 * time.Second, false},
+		{6, 4 * time.Second, false},
+		{9, 32 * time.Second, false},
+		{10, 15 * time.Minute, true},
+		{25, 15 * time.Minute, true},
+	}
+	for _, c := range cases {
+		d, lockout := Account.Decide(c.failures)
+		if d != c.delay || lockout != c.lockout {
+			t.Errorf("Decide(%d) = %v, %v; want %v, %v", c.failures, d, lockout, c.delay, c.lockout)
+		}
+	}
+}
+
+func TestDecideIPNeverLocksOut(t *testing.T) {
+	cases := []struct {
+		failures int
+		delay    time.Duration
+	}{
+		{10, 0},
+		{11, time.Second},
+		{20, 512 * time.Second},
+		{21, 15 * time.Minute},
+		{1000, 15 * time.Minute},
+	}
+	for _, c := range cases {
+		d, lockout := IP.Decide(c.failures)
+		if d != c.delay || lockout {
+			t.Errorf("Decide(%d) = %v, %v; want %v, false", c.failures, d, lockout, c.delay)
+		}
+	}
+}
+
+func TestDecideCapsAtMaxDelay(t *testing.T) {
+	p := Policy{FreeAttempts: 0, BaseDelay: 3 * time.Second, MaxDelay: 10 * time.Second}
+	for failures, want := range map[int]time.Duration{1: 3 * time.Second, 2: 6 * time.Second, 3: 10 * time.Second, 50: 10 * time.Second} {
+		if d, _ := p.Decide(failures); d != want {
+			t.Errorf("Decide(%d) = %v, want %v", failures, d, want)
+		}
+	}
+}
+
+func TestDecideLockoutThreshold(t *testing.T) {
+	p := Policy{FreeAttempts: 1, BaseDelay: time.Second, MaxDelay: time.Minute, LockoutAfter: 3, LockoutFor: time.Hour}
+	if d, lockout := p.Decide(2); lockout || d != time.Second {
+		t.Fatalf("Decide(2) = %v, %v; want 1s, false", d, lockout)
+	}
+	if d, lockout := p.Decide(3); !lockout || d != time.Hour {
+		t.Fatalf("Decide(3) = %v, %v; want 1h, true", d, lockout)
+	}
+}
+
+func TestDecidePasswordReset(t *testing.T) {
+	cases := []struct {
+		requests int
+		delay    time.Duration
+	}{
+		{3, 0},
+		{4, time.Minute},
+		{5, 2 * time.Minute},
+		{10, time.Hour},
+	}
+	for _, c := range cases {
+		d, lockout := PasswordReset.Decide(c.requests)
+		if d != c.delay || lockout {
+			t.Errorf("Decide(%d) = %v, %v; want %v, false", c.requests, d, lockout, c.delay)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package totp
+
+import (
+	"testing"
+	"time"
+)
+
+// segredo ASCII "12345678901234567890" dos vetores SHA1 do apêndice B da RFC 6238
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestRFC6238Vectors(t *testing.T) {
+	// a RFC usa 8 dígitos; com 6 ficam os 6 últimos
+	cases := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+	for _, c := range cases {
+		step, ok := Validate(rfcSecret, c.code, time.Unix(c.unix, 0), 0)
+		if !ok {
+			t.Errorf("T=%d: code %s rejected", c.unix, c.code)
+			continue
+		}
+		if want := c.unix / period; step != want {
+			t.Errorf("T=%d: step = %d, want %d", c.unix, step, want)
+		}
+	}
+}
+
+func TestValidateSkew(t *testing.T) {
+	at := time.Unix(1234567890, 0)
+	now := at.Unix() / period
+	key, err := encoding.DecodeString(rfcSecret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		name  string
+		step  int64
+		valid bool
+	}{
+		{"two steps behind", now - 2, false},
+		{"one step behind", now - 1, true},
+		{"current step", now, true},
+		{"one step ahead", now + 1, true},
+		{"two steps ahead", now + 2, false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			step, ok := Validate(rfcSecret, generate(key, c.step), at, 0)
+			if ok != c.valid {
+				t.Fatalf("valid = %v, want %v", ok, c.valid)
+			}
+			if ok && step != c.step {
+				t.Fatalf("step = %d, want %d", step, c.step)
+			}
+		})
+	}
+}
+
+func TestValidateRejectsReplay(t *testing.T) {
+	at := time.Unix(1234567890, 0)
+	step, ok := Validate(rfcSecret, "005924", at, 0)
+	if !ok {
+		t.Fatal("first use rejected")
+	}
+	if _, ok := Validate(rfcSecret, "005924", at, step); ok {
+		t.Fatal("same step accepted twice")
+	}
+}
+
+func TestValidateMalformed(t *testing.T) {
+	at := time.Unix(1234567890, 0)
+	for _, code := range []string{"", "00592", "0059244", "abcdef"} {
+		if _, ok := Validate(rfcSecret, code, at, 0); ok {
+			t.Errorf("code %q accepted", code)
+		}
+	}
+	if _, ok := Validate("not base32!", "005924", at, 0); ok {
+		t.Error("invalid secret accepted")
+	}
+}
+
+func TestValidateLowercaseSecretAndSpaces(t *testing.T) {
+	at := time.Unix(1234567890, 0)
+	if _, ok := Validate("gezdgnbvgy3tqojqgezdgnbvgy3tqojq", " 005924 ", at, 0); !ok {
+		t.Fatal("lowercase secret or padded code rejected")
+	}
+}
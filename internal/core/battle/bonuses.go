@@ -0,0 +1,94 @@
+package battle
+
+import (
+	"math"
+	"slices"
+	"time"
+
+	"github.com/gabrieldemesio/solo-leveling-go-mvp-v2/internal/core/ruleset"
+	"github.com/gabrieldemesio/solo-leveling-go-mvp-v2/internal/core/stats"
+)
+
+const (
+	// focus: +2% de XP por ponto acima da base, até +50%
+	focusXPPerPoint = 0.02
+	focusXPCap      = 0.50
+	// energy: sessões longas (>= EnergyMinMinutes) ganham +1% de XP por ponto, até +25%
+	EnergyMinMinutes = 45
+	energyXPPerPoint = 0.01
+	energyXPCap      = 0.25
+	// creativity: quests com a tag CreativeTag ganham +3% de gold por ponto, até +60%
+	CreativeTag            = "creative"
+	creativityGoldPerPoint = 0.03
+	creativityGoldCap      = 0.60
+	// discipline: num abandono, salva 2% do XP base por ponto, até 30%
+	disciplineSalvagePerPoint = 0.02
+	disciplineSalvageCap      = 0.30
+)
+
+// Gate é o que a batalha precisa saber do gate que está fechando. Minutes é o alvo; Elapsed é o
+// tempo medido pelo servidor desde a abertura.
+type Gate struct {
+	Minutes        int
+	Elapsed        time.Duration
+	RankMultiplier float64
+	QuestWeight    int
+	QuestTags      []string
+	Quality        float64
+	Success        bool
+}
+
+// Breakdown explica a recompensa: a base de ComputeRewards mais o que cada stat, o loot e os itens somaram.
+// XP e Gold são o que o usuário de fato recebe.
+type Breakdown struct {
+	Minutes           int   `json:"minutes"`
+	BaseXP            int64 `json:"baseXp"`
+	BaseGold          int64 `json:"baseGold"`
+	FocusXP           int64 `json:"focusXp"`
+	EnergyXP          int64 `json:"energyXp"`
+	CreativityGold    int64 `json:"creativityGold"`
+	PartialXP         int64 `json:"partialXp"`
+	DisciplineSalvage int64 `json:"disciplineSalvageXp"`
+	CritXP            int64 `json:"critXp"`
+	LootGold          int64 `json:"lootGold"`
+	PotionXP          int64 `json:"potionXp"`
+	XP                int64 `json:"xp"`
+	Gold              int64 `json:"gold"`
+}
+
+// Resolve calcula a recompensa com os bônus dos stats do hunter. Sucesso paga o alvo inteiro. Abandono
+// calcula a base só com os minutos cumpridos e não dá gold: rende PartialCredit dessa base mais o que
+// a discipline salvar.
+func Resolve(rules *ruleset.Ruleset, g Gate, hunter map[string]int) Breakdown {
+	var b Breakdown
+	b.Minutes = g.Minutes
+	if !g.Success {
+		b.Minutes = CreditedMinutes(g.Minutes, g.Elapsed)
+	}
+	b.BaseXP, b.BaseGold = ComputeRewards(rules, b.Minutes, g.RankMultiplier, g.QuestWeight, g.Quality)
+	if !g.Success {
+		b.PartialXP = int64(math.Floor(float64(b.BaseXP) * rules.PartialCredit))
+		b.DisciplineSalvage = bonus(b.BaseXP, hunter["discipline"], disciplineSalvagePerPoint, disciplineSalvageCap)
+		b.XP = b.PartialXP + b.DisciplineSalvage
+		return b
+	}
+	b.FocusXP = bonus(b.BaseXP, hunter["focus"], focusXPPerPoint, focusXPCap)
+	if g.Minutes >= EnergyMinMinutes {
+		b.EnergyXP = bonus(b.BaseXP, hunter["energy"], energyXPPerPoint, energyXPCap)
+	}
+	if slices.Contains(g.QuestTags, CreativeTag) {
+		b.CreativityGold = bonus(b.BaseGold, hunter["creativity"], creativityGoldPerPoint, creativityGoldCap)
+	}
+	b.XP = b.BaseXP + b.FocusXP + b.EnergyXP
+	b.Gold = b.BaseGold + b.CreativityGold
+	return b
+}
+
+// bonus é a fração de base que o stat rende: perPoint por ponto acima da base, limitado a limit.
+func bonus(base int64, stat int, perPoint, limit float64) int64 {
+	pts := stat - stats.Base
+	if pts <= 0 || base <= 0 {
+		return 0
+	}
+	return int64(math.Floor(float64(base) * math.Min(float64(pts)*perPoint, limit)))
+}
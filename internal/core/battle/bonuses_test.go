@@ -0,0 +1,91 @@
+package battle
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gabrieldemesio/solo-leveling-go-mvp-v2/internal/core/ruleset"
+)
+
+// bonusRules dá 600 XP e 300 de gold num gate de 60 min com multiplicador, peso e qualidade 1.
+func bonusRules() *ruleset.Ruleset {
+	return &ruleset.Ruleset{XPPerMinute: 10, GoldRatio: 0.5, QualityMin: 0.5, QualityMax: 1.5, PartialCredit: 0.5}
+}
+
+func hunter(focus, discipline, energy, creativity int) map[string]int {
+	return map[string]int{"focus": focus, "discipline": discipline, "energy": energy, "creativity": creativity}
+}
+
+func TestResolveSuccessBonuses(t *testing.T) {
+	cases := []struct {
+		name    string
+		minutes int
+		tags    []string
+		hunter  map[string]int
+		want    Breakdown
+	}{
+		{"base stats", 60, nil, hunter(1, 1, 1, 1),
+			Breakdown{Minutes: 60, BaseXP: 600, BaseGold: 300, XP: 600, Gold: 300}},
+		{"no stats at all", 60, []string{CreativeTag}, map[string]int{},
+			Breakdown{Minutes: 60, BaseXP: 600, BaseGold: 300, XP: 600, Gold: 300}},
+		{"stats below base", 60, []string{CreativeTag}, hunter(0, 0, 0, 0),
+			Breakdown{Minutes: 60, BaseXP: 600, BaseGold: 300, XP: 600, Gold: 300}},
+		{"focus", 60, nil, hunter(11, 1, 1, 1),
+			Breakdown{Minutes: 60, BaseXP: 600, BaseGold: 300, FocusXP: 120, XP: 720, Gold: 300}},
+		{"focus capped", 60, nil, hunter(100, 1, 1, 1),
+			Breakdown{Minutes: 60, BaseXP: 600, BaseGold: 300, FocusXP: 300, XP: 900, Gold: 300}},
+		{"energy on a long gate", 60, nil, hunter(1, 1, 11, 1),
+			Breakdown{Minutes: 60, BaseXP: 600, BaseGold: 300, EnergyXP: 60, XP: 660, Gold: 300}},
+		{"energy capped", 60, nil, hunter(1, 1, 100, 1),
+			Breakdown{Minutes: 60, BaseXP: 600, BaseGold: 300, EnergyXP: 150, XP: 750, Gold: 300}},
+		{"energy ignored on a short gate", 30, nil, hunter(1, 1, 11, 1),
+			Breakdown{Minutes: 30, BaseXP: 300, BaseGold: 150, XP: 300, Gold: 150}},
+		{"creativity on a creative quest", 60, []string{"study", CreativeTag}, hunter(1, 1, 1, 11),
+			Breakdown{Minutes: 60, BaseXP: 600, BaseGold: 300, CreativityGold: 90, XP: 600, Gold: 390}},
+		{"creativity capped", 60, []string{CreativeTag}, hunter(1, 1, 1, 100),
+			Breakdown{Minutes: 60, BaseXP: 600, BaseGold: 300, CreativityGold: 180, XP: 600, Gold: 480}},
+		{"creativity ignored without the tag", 60, []string{"study"}, hunter(1, 1, 1, 11),
+			Breakdown{Minutes: 60, BaseXP: 600, BaseGold: 300, XP: 600, Gold: 300}},
+		{"discipline does nothing on success", 60, nil, hunter(1, 11, 1, 1),
+			Breakdown{Minutes: 60, BaseXP: 600, BaseGold: 300, XP: 600, Gold: 300}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			g := Gate{Minutes: c.minutes, Elapsed: time.Duration(c.minutes) * time.Minute,
+				RankMultiplier: 1, QuestWeight: 1, QuestTags: c.tags, Quality: 1, Success: true}
+			if got := Resolve(bonusRules(), g, c.hunter); got != c.want {
+				t.Errorf("Resolve = %+v\nwant      %+v", got, c.want)
+			}
+		})
+	}
+}
+
+func TestResolvePartialCredit(t *testing.T) {
+	cases := []struct {
+		name    string
+		elapsed time.Duration
+		hunter  map[string]int
+		want    Breakdown
+	}{
+		// metade do gate cumprida: base de 30 min, metade dela de crédito, sem gold
+		{"half done", 30 * time.Minute, hunter(11, 1, 11, 11),
+			Breakdown{Minutes: 30, BaseXP: 300, BaseGold: 150, PartialXP: 150, XP: 150}},
+		{"discipline salvage", 30 * time.Minute, hunter(1, 11, 1, 1),
+			Breakdown{Minutes: 30, BaseXP: 300, BaseGold: 150, PartialXP: 150, DisciplineSalvage: 60, XP: 210}},
+		{"discipline capped", 30 * time.Minute, hunter(1, 100, 1, 1),
+			Breakdown{Minutes: 30, BaseXP: 300, BaseGold: 150, PartialXP: 150, DisciplineSalvage: 90, XP: 240}},
+		{"nothing done", 0, hunter(1, 100, 1, 1),
+			Breakdown{}},
+		// passar do alvo não credita além dele
+		{"elapsed past the target", 90 * time.Minute, hunter(1, 1, 1, 1),
+			Breakdown{Minutes: 60, BaseXP: 600, BaseGold: 300, PartialXP: 300, XP: 300}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			g := Gate{Minutes: 60, Elapsed: c.elapsed, RankMultiplier: 1, QuestWeight: 1, Quality: 1}
+			if got := Resolve(bonusRules(), g, c.hunter); got != c.want {
+				t.Errorf("Resolve = %+v\nwant      %+v", got, c.want)
+			}
+		})
+	}
+}
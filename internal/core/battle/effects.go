@@ -0,0 +1,13 @@
+package battle
+
+import "math"
+
+// ApplyXPMultiplier aplica uma poção de XP sobre o XP total do gate (já com stats e crítico).
+// mult abaixo de 1 não tira nada.
+func (b *Breakdown) ApplyXPMultiplier(mult float64) {
+	if mult <= 1 || b.XP <= 0 {
+		return
+	}
+	b.PotionXP = int64(math.Floor(float64(b.XP) * (mult - 1)))
+	b.XP += b.PotionXP
+}
@@ -0,0 +1,34 @@
+package battle
+
+import (
+	"time"
+
+	"github.com/gabrieldemesio/solo-leveling-go-mvp-v2/internal/core/ruleset"
+)
+
+// SuccessGrace é a folga pra um "success" valer um pouco antes do alvo (latência, relógio do cliente).
+const SuccessGrace = 30 * time.Second
+
+// MaxPause é quanto tempo de pausa o rank permite descontar; rank fora do ruleset não pausa.
+func MaxPause(rules *ruleset.Ruleset, rank string) time.Duration {
+	return time.Duration(rules.MaxPauseMinutes[rank]) * time.Minute
+}
+
+// Elapsed é o tempo de foco entre start e now: o relógio corrido menos as pausas, descontadas só até
+// maxPause. Pausa além do limite conta como tempo normal.
+func Elapsed(start, now time.Time, paused, maxPause time.Duration) time.Duration {
+	return now.Sub(start) - min(paused, maxPause)
+}
+
+// Reached diz se o gate durou o alvo, com a folga de SuccessGrace.
+func Reached(targetMinutes int, elapsed time.Duration) bool {
+	return elapsed+SuccessGrace >= time.Duration(targetMinutes)*time.Minute
+}
+
+// CreditedMinutes são os minutos inteiros cumpridos, limitados ao alvo.
+func CreditedMinutes(targetMinutes int, elapsed time.Duration) int {
+	if elapsed <= 0 {
+		return 0
+	}
+	return min(int(elapsed/time.Minute), targetMinutes)
+}
@@ -0,0 +1,77 @@
+package battle
+
+import (
+	"math"
+	"math/rand/v2"
+
+	"github.com/gabrieldemesio/solo-leveling-go-mvp-v2/internal/core/ruleset"
+)
+
+// Drop é um item que caiu no gate.
+type Drop struct {
+	Item   string `json:"item"`
+	Rarity string `json:"rarity"`
+}
+
+// Loot é o resultado da fase de sorte. Mesmo seed + mesmo ruleset + mesma recompensa base = mesmo Loot.
+type Loot struct {
+	Seed      int64  `json:"seed,string"`
+	Crit      bool   `json:"crit"`
+	CritXP    int64  `json:"critXp"`
+	BonusGold int64  `json:"bonusGold"`
+	Drops     []Drop `json:"drops"`
+}
+
+// RollLoot sorteia crítico, gold bônus e drop, sempre nessa ordem e sempre consumindo os mesmos
+// números do RNG, pra que mudar uma chance no ruleset não embaralhe os outros sorteios.
+// xp e gold são a recompensa já com os bônus de stats.
+func RollLoot(rules *ruleset.Ruleset, dungeonRank string, seed int64, xp, gold int64) Loot {
+	l := Loot{Seed: seed, Drops: []Drop{}}
+	rng := rand.New(rand.NewPCG(uint64(seed), uint64(seed)^0x9e3779b97f4a7c15))
+	cfg := rules.Loot
+
+	critRoll, goldRoll, goldFrac := rng.Float64(), rng.Float64(), rng.Float64()
+	dropRoll, pick := rng.Float64(), rng.Float64()
+
+	if critRoll < cfg.CritChance {
+		l.Crit = true
+		l.CritXP = int64(math.Floor(float64(xp) * (cfg.CritMultiplier - 1)))
+	}
+	if goldRoll < cfg.BonusGoldChance {
+		frac := cfg.BonusGoldMin + goldFrac*(cfg.BonusGoldMax-cfg.BonusGoldMin)
+		l.BonusGold = int64(math.Floor(float64(gold) * frac))
+	}
+	if t, ok := cfg.Tables[dungeonRank]; ok && dropRoll < t.DropChance {
+		if d, ok := pickDrop(t.Drops, pick); ok {
+			l.Drops = append(l.Drops, d)
+		}
+	}
+	return l
+}
+
+// AddLoot soma o crítico e o gold bônus na recompensa.
+func (b *Breakdown) AddLoot(l Loot) {
+	b.CritXP, b.LootGold = l.CritXP, l.BonusGold
+	b.XP += l.CritXP
+	b.Gold += l.BonusGold
+}
+
+// pickDrop escolhe pelo peso; u é um número em [0,1).
+func pickDrop(drops []ruleset.LootDrop, u float64) (Drop, bool) {
+	total := 0
+	for _, d := range drops {
+		total += d.Weight
+	}
+	if total <= 0 {
+		return Drop{}, false
+	}
+	n := int(u * float64(total))
+	for _, d := range drops {
+		if n < d.Weight {
+			return Drop{Item: d.Item, Rarity: d.Rarity}, true
+		}
+		n -= d.Weight
+	}
+	last := drops[len(drops)-1]
+	return Drop{Item: last.Item, Rarity: last.Rarity}, true
+}
@@ -0,0 +1,123 @@
+package battle
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/gabrieldemesio/solo-leveling-go-mvp-v2/internal/core/ruleset"
+)
+
+var (
+	potion = ruleset.LootDrop{Item: "xp_potion", Rarity: "common", Weight: 70}
+	shield = ruleset.LootDrop{Item: "streak_shield", Rarity: "rare", Weight: 25}
+	key    = ruleset.LootDrop{Item: "rank_key", Rarity: "epic", Weight: 5}
+)
+
+// testRules é fixo de propósito: mexer no default.json não pode quebrar os seeds pinados aqui.
+func testRules() *ruleset.Ruleset {
+	return &ruleset.Ruleset{Loot: ruleset.Loot{
+		CritChance:      0.3,
+		CritMultiplier:  1.5,
+		BonusGoldChance: 0.4,
+		BonusGoldMin:    0.1,
+		BonusGoldMax:    0.5,
+		Tables: map[string]ruleset.LootTable{
+			"C": {DropChance: 0.5, Drops: []ruleset.LootDrop{potion, shield, key}},
+		},
+	}}
+}
+
+func drops(ds ...ruleset.LootDrop) []Drop {
+	out := []Drop{}
+	for _, d := range ds {
+		out = append(out, Drop{Item: d.Item, Rarity: d.Rarity})
+	}
+	return out
+}
+
+func TestRollLootPinnedSeeds(t *testing.T) {
+	cases := []struct {
+		seed int64
+		want Loot
+	}{
+		{0, Loot{Drops: drops()}},
+		{1, Loot{Crit: true, CritXP: 500, Drops: drops()}},
+		{10, Loot{BonusGold: 206, Drops: drops()}},
+		{2, Loot{Drops: drops(potion)}},
+		{15, Loot{Drops: drops(key)}},
+		{19, Loot{BonusGold: 95, Drops: drops(shield)}},
+		{39, Loot{Crit: true, CritXP: 500, BonusGold: 222, Drops: drops(potion)}},
+		{-2, Loot{Crit: true, CritXP: 500, BonusGold: 249, Drops: drops()}},
+	}
+	for _, c := range cases {
+		c.want.Seed = c.seed
+		got := RollLoot(testRules(), "C", c.seed, 1000, 500)
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("seed %d: got %+v, want %+v", c.seed, got, c.want)
+		}
+	}
+}
+
+func TestRollLootRankWithoutTable(t *testing.T) {
+	got := RollLoot(testRules(), "E", 39, 1000, 500)
+	want := Loot{Seed: 39, Crit: true, CritXP: 500, BonusGold: 222, Drops: drops()}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+}
+
+// mudar uma chance não pode mexer nos outros sorteios do mesmo seed
+func TestRollLootRollsAreIndependent(t *testing.T) {
+	noDrop := testRules()
+	noDrop.Loot.Tables["C"] = ruleset.LootTable{DropChance: 0, Drops: []ruleset.LootDrop{potion}}
+	if got := RollLoot(noDrop, "C", 39, 1000, 500); !got.Crit || got.BonusGold != 222 || len(got.Drops) != 0 {
+		t.Errorf("drop chance 0: got %+v", got)
+	}
+	noCrit := testRules()
+	noCrit.Loot.CritChance = 0
+	got := RollLoot(noCrit, "C", 39, 1000, 500)
+	if got.Crit || got.CritXP != 0 || got.BonusGold != 222 || !reflect.DeepEqual(got.Drops, drops(potion)) {
+		t.Errorf("crit chance 0: got %+v", got)
+	}
+}
+
+func TestRollLootDeterministic(t *testing.T) {
+	for seed := int64(-50); seed < 50; seed++ {
+		a := RollLoot(testRules(), "C", seed, 777, 333)
+		b := RollLoot(testRules(), "C", seed, 777, 333)
+		if !reflect.DeepEqual(a, b) {
+			t.Fatalf("seed %d: %+v != %+v", seed, a, b)
+		}
+	}
+}
+
+func TestPickDrop(t *testing.T) {
+	table := []ruleset.LootDrop{potion, shield, key}
+	cases := []struct {
+		name  string
+		drops []ruleset.LootDrop
+		u     float64
+		want  string
+		ok    bool
+	}{
+		{"empty table", nil, 0.5, "", false},
+		{"all weights zero", []ruleset.LootDrop{{Item: "a"}, {Item: "b"}}, 0.5, "", false},
+		{"u zero picks first", table, 0, "xp_potion", true},
+		{"end of first band", table, 0.6999, "xp_potion", true},
+		{"start of second band", table, 0.70, "streak_shield", true},
+		{"end of second band", table, 0.9499, "streak_shield", true},
+		{"start of last band", table, 0.95, "rank_key", true},
+		{"u just below one", table, 0.999999, "rank_key", true},
+		{"u out of range falls back to last", table, 1, "rank_key", true},
+		{"zero weight is never picked", []ruleset.LootDrop{{Item: "a", Weight: 0}, {Item: "b", Weight: 10}}, 0, "b", true},
+		{"single entry", []ruleset.LootDrop{{Item: "a", Weight: 3}}, 0.99, "a", true},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			d, ok := pickDrop(c.drops, c.u)
+			if ok != c.ok || d.Item != c.want {
+				t.Fatalf("pickDrop(%v) = %+v, %v; want %q, %v", c.u, d, ok, c.want, c.ok)
+			}
+		})
+	}
+}
@@ -1,41 +1,31 @@
 package battle
 
-func RankMultiplier(rank string) float64 {
-	switch rank {
-	case "S":
-		return 2.2
-	case "A":
-		return 1.8
-	case "B":
-		return 1.5
-	case "C":
-		return 1.3
-	case "D":
-		return 1.1
-	case "E":
-		fallthrough
-	default:
-		return 1.0
+import "github.com/gabrieldemesio/solo-leveling-go-mvp-v2/internal/core/ruleset"
+
+// RankMultiplier vem do ruleset; rank desconhecido vale 1.0.
+func RankMultiplier(rules *ruleset.Ruleset, rank string) float64 {
+	if m, ok := rules.RankMultipliers[rank]; ok {
+		return m
 	}
+	return 1.0
 }
-func ComputeRewards(minutes int, dungeonMultiplier float64, questWeight int, quality float64) (xp, gold int64) {
+func ComputeRewards(rules *ruleset.Ruleset, minutes int, dungeonMultiplier float64, questWeight int, quality float64) (xp, gold int64) {
 	if minutes < 0 {
 		minutes = 0
 	}
-	base := 10.0
 	qw := questWeight
 	if qw <= 0 {
 		qw = 1
 	}
-	if quality < 0.5 {
-		quality = 0.5
+	if quality < rules.QualityMin {
+		quality = rules.QualityMin
 	}
-	if quality > 1.5 {
-		quality = 1.5
+	if quality > rules.QualityMax {
+		quality = rules.QualityMax
 	}
-	xpF := base * float64(minutes) * dungeonMultiplier * float64(qw) * quality
+	xpF := rules.XPPerMinute * float64(minutes) * dungeonMultiplier * float64(qw) * quality
 	if xpF < 0 {
 		xpF = 0
 	}
-	return int64(xpF), int64(xpF / 2.0)
+	return int64(xpF), int64(xpF * rules.GoldRatio)
 }
@@ -0,0 +1,67 @@
+package leveling
+
+import (
+	"errors"
+	"math"
+)
+
+// Curve define quanto XP cada nível custa: passar do nível L pro L+1 custa Base * L^Exponent.
+// O nível é sempre derivado do XP acumulado, nunca guardado por conta própria.
+type Curve struct {
+	Base     float64
+	Exponent float64
+	// MaxLevel é o teto; XP acima disso continua contando mas não sobe mais de nível.
+	MaxLevel int
+	// StatPointsPerLevel é quantos pontos de stat cada nível ganho dá.
+	StatPointsPerLevel int
+}
+
+// Default: nível 2 com 100 XP acumulado, 3 com 382, 4 com 901...
+var Default = Curve{Base: 100, Exponent: 1.5, MaxLevel: 100, StatPointsPerLevel: 3}
+
+func (c Curve) Validate() error {
+	if c.Base <= 0 {
+		return errors.New("leveling: base must be > 0")
+	}
+	if c.Exponent < 0 {
+		return errors.New("leveling: exponent must be >= 0")
+	}
+	if c.MaxLevel < 1 {
+		return errors.New("leveling: max level must be >= 1")
+	}
+	if c.StatPointsPerLevel < 0 {
+		return errors.New("leveling: stat points per level must be >= 0")
+	}
+	return nil
+}
+
+// XPForLevel é o XP acumulado necessário pra chegar no nível (nível 1 = 0).
+func (c Curve) XPForLevel(level int) int64 {
+	var total float64
+	for l := 1; l < level; l++ {
+		total += math.Floor(c.Base * math.Pow(float64(l), c.Exponent))
+	}
+	return int64(total)
+}
+
+// LevelFor devolve o nível correspondente ao XP acumulado.
+func (c Curve) LevelFor(xp int64) int {
+	level := 1
+	var total float64
+	for level < c.MaxLevel {
+		total += math.Floor(c.Base * math.Pow(float64(level), c.Exponent))
+		if float64(xp) < total {
+			break
+		}
+		level++
+	}
+	return level
+}
+
+// StatPointsFor é quanto uma subida de from pra to rende em pontos de stat.
+func (c Curve) StatPointsFor(from, to int) int {
+	if to <= from {
+		return 0
+	}
+	return (to - from) * c.StatPointsPerLevel
+}
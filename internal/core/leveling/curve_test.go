@@ -0,0 +1,112 @@
+package leveling
+
+import "testing"
+
+func TestXPForLevel(t *testing.T) {
+	cases := []struct {
+		level int
+		xp    int64
+	}{
+		{0, 0},
+		{1, 0},
+		{2, 100},
+		{3, 382},
+		{4, 901},
+		{5, 1701},
+	}
+	for _, c := range cases {
+		if got := Default.XPForLevel(c.level); got != c.xp {
+			t.Errorf("XPForLevel(%d) = %d, want %d", c.level, got, c.xp)
+		}
+	}
+}
+
+func TestLevelForThresholds(t *testing.T) {
+	cases := []struct {
+		xp    int64
+		level int
+	}{
+		{0, 1},
+		{99, 1},
+		{100, 2},
+		{381, 2},
+		{382, 3},
+		{900, 3},
+		{901, 4},
+	}
+	for _, c := range cases {
+		if got := Default.LevelFor(c.xp); got != c.level {
+			t.Errorf("LevelFor(%d) = %d, want %d", c.xp, got, c.level)
+		}
+	}
+}
+
+// LevelFor e XPForLevel têm que concordar em toda a curva: o XP exato de um nível já é aquele nível.
+func TestLevelForMatchesXPForLevel(t *testing.T) {
+	for level := 2; level <= Default.MaxLevel; level++ {
+		xp := Default.XPForLevel(level)
+		if got := Default.LevelFor(xp); got != level {
+			t.Fatalf("LevelFor(XPForLevel(%d)) = %d", level, got)
+		}
+		if got := Default.LevelFor(xp - 1); got != level-1 {
+			t.Fatalf("LevelFor(XPForLevel(%d)-1) = %d, want %d", level, got, level-1)
+		}
+	}
+}
+
+func TestMultiLevelUpFromOneReward(t *testing.T) {
+	// um gate que leva de 50 pra 1000 XP passa por 2, 3 e 4 de uma vez
+	from, to := Default.LevelFor(50), Default.LevelFor(50+950)
+	if from != 1 || to != 4 {
+		t.Fatalf("levels = %d -> %d, want 1 -> 4", from, to)
+	}
+	if got := Default.StatPointsFor(from, to); got != 9 {
+		t.Errorf("StatPointsFor(1, 4) = %d, want 9", got)
+	}
+}
+
+func TestLevelCap(t *testing.T) {
+	c := Curve{Base: 100, Exponent: 1.5, MaxLevel: 5, StatPointsPerLevel: 3}
+	if got := c.LevelFor(c.XPForLevel(5)); got != 5 {
+		t.Errorf("LevelFor at cap = %d, want 5", got)
+	}
+	if got := c.LevelFor(1 << 40); got != 5 {
+		t.Errorf("LevelFor above cap = %d, want 5", got)
+	}
+	if got := c.StatPointsFor(1, c.LevelFor(1<<40)); got != 12 {
+		t.Errorf("StatPointsFor up to cap = %d, want 12", got)
+	}
+}
+
+func TestStatPointsFor(t *testing.T) {
+	cases := []struct {
+		from, to, points int
+	}{
+		{1, 2, 3},
+		{3, 7, 12},
+		{5, 5, 0},
+		{6, 4, 0},
+	}
+	for _, c := range cases {
+		if got := Default.StatPointsFor(c.from, c.to); got != c.points {
+			t.Errorf("StatPointsFor(%d, %d) = %d, want %d", c.from, c.to, got, c.points)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	bad := []Curve{
+		{Base: 0, Exponent: 1.5, MaxLevel: 100},
+		{Base: 100, Exponent: -1, MaxLevel: 100},
+		{Base: 100, Exponent: 1.5, MaxLevel: 0},
+		{Base: 100, Exponent: 1.5, MaxLevel: 100, StatPointsPerLevel: -1},
+	}
+	for _, c := range bad {
+		if err := c.Validate(); err == nil {
+			t.Errorf("Validate(%+v) = nil, want error", c)
+		}
+	}
+	if err := Default.Validate(); err != nil {
+		t.Errorf("Validate(Default) = %v", err)
+	}
+}
@@ -0,0 +1,88 @@
+package rank
+
+const (
+	E        = "E"
+	D        = "D"
+	C        = "C"
+	B        = "B"
+	A        = "A"
+	S        = "S"
+	National = "National"
+)
+
+// Order vai do mais fraco pro mais forte. National é só de hunter: não existe dungeon National.
+var Order = []string{E, D, C, B, A, S, National}
+
+// Requirement é o que o hunter precisa pra tentar a reavaliação pro rank: nível mínimo e
+// quantos gates já fechou com sucesso no rank atual.
+type Requirement struct {
+	Level  int `json:"level"`
+	Clears int `json:"clears"`
+}
+
+var Requirements = map[string]Requirement{
+	D:        {Level: 5, Clears: 3},
+	C:        {Level: 12, Clears: 5},
+	B:        {Level: 20, Clears: 8},
+	A:        {Level: 30, Clears: 10},
+	S:        {Level: 45, Clears: 15},
+	National: {Level: 60, Clears: 20},
+}
+
+// ReassessmentMinMinutes é a duração mínima de um gate de reavaliação.
+const ReassessmentMinMinutes = 30
+
+// Index devolve a posição em Order, ou -1 se o rank não existe.
+func Index(r string) int {
+	for i, o := range Order {
+		if o == r {
+			return i
+		}
+	}
+	return -1
+}
+
+// IsDungeon diz se dá pra abrir um gate desse rank.
+func IsDungeon(r string) bool {
+	i := Index(r)
+	return i >= 0 && r != National
+}
+
+// MaxDungeon é o rank de gate mais alto que o hunter pode abrir.
+func MaxDungeon(hunter string) string {
+	if hunter == National {
+		return S
+	}
+	return hunter
+}
+
+func CanEnter(hunter, dungeon string) bool {
+	return IsDungeon(dungeon) && Index(dungeon) <= Index(MaxDungeon(hunter))
+}
+
+// Next é o próximo tier; false se o hunter já está no topo.
+func Next(hunter string) (string, bool) {
+	i := Index(hunter)
+	if i < 0 || i+1 >= len(Order) {
+		return "", false
+	}
+	return Order[i+1], true
+}
+
+// ReassessmentDungeon é o rank do gate de reavaliação pra chegar em target (pra National é um S).
+func ReassessmentDungeon(target string) string {
+	if target == National {
+		return S
+	}
+	return target
+}
+
+// Eligible diz se o hunter já pode tentar a reavaliação pro próximo tier.
+func Eligible(hunter string, level, clears int) bool {
+	next, ok := Next(hunter)
+	if !ok {
+		return false
+	}
+	req := Requirements[next]
+	return level >= req.Level && clears >= req.Clears
+}
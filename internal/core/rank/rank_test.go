@@ -0,0 +1,104 @@
+package rank
+
+import "testing"
+
+func TestNext(t *testing.T) {
+	cases := []struct {
+		hunter, next string
+		ok           bool
+	}{
+		{E, D, true},
+		{D, C, true},
+		{C, B, true},
+		{B, A, true},
+		{A, S, true},
+		{S, National, true},
+		{National, "", false},
+		{"Z", "", false},
+	}
+	for _, c := range cases {
+		next, ok := Next(c.hunter)
+		if next != c.next || ok != c.ok {
+			t.Errorf("Next(%q) = %q, %v; want %q, %v", c.hunter, next, ok, c.next, c.ok)
+		}
+	}
+}
+
+func TestEligibleThresholds(t *testing.T) {
+	cases := []struct {
+		hunter        string
+		level, clears int
+		want          bool
+	}{
+		{E, 5, 3, true},
+		{E, 4, 3, false},
+		{E, 5, 2, false},
+		{D, 12, 5, true},
+		{D, 11, 5, false},
+		{C, 20, 8, true},
+		{C, 20, 7, false},
+		{B, 30, 10, true},
+		{A, 45, 15, true},
+		{A, 44, 15, false},
+		{S, 60, 20, true},
+		{S, 60, 19, false},
+		// National é o topo: não tem pra onde ir
+		{National, 100, 100, false},
+	}
+	for _, c := range cases {
+		if got := Eligible(c.hunter, c.level, c.clears); got != c.want {
+			t.Errorf("Eligible(%s, %d, %d) = %v, want %v", c.hunter, c.level, c.clears, got, c.want)
+		}
+	}
+}
+
+func TestNationalIsNotADungeon(t *testing.T) {
+	if IsDungeon(National) {
+		t.Error("IsDungeon(National) = true")
+	}
+	if MaxDungeon(National) != S {
+		t.Errorf("MaxDungeon(National) = %s, want S", MaxDungeon(National))
+	}
+	if CanEnter(National, National) {
+		t.Error("CanEnter(National, National) = true")
+	}
+	if !CanEnter(National, S) {
+		t.Error("CanEnter(National, S) = false")
+	}
+}
+
+func TestCanEnter(t *testing.T) {
+	cases := []struct {
+		hunter, dungeon string
+		want            bool
+	}{
+		{E, E, true},
+		{E, D, false},
+		{C, D, true},
+		{C, B, false},
+		{S, S, true},
+		{B, "Z", false},
+	}
+	for _, c := range cases {
+		if got := CanEnter(c.hunter, c.dungeon); got != c.want {
+			t.Errorf("CanEnter(%s, %s) = %v, want %v", c.hunter, c.dungeon, got, c.want)
+		}
+	}
+}
+
+// a reavaliação pra um tier se faz num gate daquele tier; pra National, num S
+func TestReassessmentDungeon(t *testing.T) {
+	for _, r := range []string{D, C, B, A, S} {
+		if got := ReassessmentDungeon(r); got != r {
+			t.Errorf("ReassessmentDungeon(%s) = %s", r, got)
+		}
+	}
+	if got := ReassessmentDungeon(National); got != S {
+		t.Errorf("ReassessmentDungeon(National) = %s, want S", got)
+	}
+	for _, r := range Order[1:] {
+		if !IsDungeon(ReassessmentDungeon(r)) {
+			t.Errorf("reassessment for %s is not an enterable dungeon", r)
+		}
+	}
+}
@@ -0,0 +1,202 @@
+package ruleset
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync/atomic"
+
+	"github.com/gabrieldemesio/solo-leveling-go-mvp-v2/internal/core/rank"
+)
+
+//go:embed default.json
+var defaultJSON []byte
+
+// Ruleset são os números de balanceamento das recompensas (gold = xp * GoldRatio). Version vai
+// gravada em cada focus_run, então toda mudança de valores precisa de uma versão nova.
+// QualityMin/Max limitam a qualidade informada pelo cliente. PartialCredit é a fração do XP dos
+// minutos cumpridos que um abandono rende. MaxPauseMinutes é quanto um gate de cada rank pode ficar
+// pausado sem contar no tempo; rank fora do mapa não pausa.
+type Ruleset struct {
+	Version         string             `json:"version"`
+	XPPerMinute     float64            `json:"xpPerMinute"`
+	GoldRatio       float64            `json:"goldRatio"`
+	QualityMin      float64            `json:"qualityMin"`
+	QualityMax      float64            `json:"qualityMax"`
+	PartialCredit   float64            `json:"partialCredit"`
+	RankMultipliers map[string]float64 `json:"rankMultipliers"`
+	MaxPauseMinutes map[string]int     `json:"maxPauseMinutes"`
+	Loot            Loot               `json:"loot"`
+}
+
+// Loot é a fase de sorte depois de um gate concluído. Chances vão de 0 a 1; BonusGoldMin/Max são
+// frações do gold base. Tables é por rank de dungeon; rank sem tabela não dropa item.
+type Loot struct {
+	CritChance      float64              `json:"critChance"`
+	CritMultiplier  float64              `json:"critMultiplier"`
+	BonusGoldChance float64              `json:"bonusGoldChance"`
+	BonusGoldMin    float64              `json:"bonusGoldMin"`
+	BonusGoldMax    float64              `json:"bonusGoldMax"`
+	Tables          map[string]LootTable `json:"tables"`
+}
+
+type LootTable struct {
+	DropChance float64    `json:"dropChance"`
+	Drops      []LootDrop `json:"drops"`
+}
+
+// LootDrop é uma entrada da tabela; a chance dela é Weight sobre a soma dos pesos da tabela.
+type LootDrop struct {
+	Item   string `json:"item"`
+	Rarity string `json:"rarity"`
+	Weight int    `json:"weight"`
+}
+
+// Default é o ruleset embutido no binário, usado quando RULESET_FILE não está setado.
+func Default() *Ruleset {
+	rs, err := Parse(defaultJSON)
+	if err != nil {
+		panic("ruleset: embedded default is invalid: " + err.Error())
+	}
+	return rs
+}
+
+func Load(path string) (*Ruleset, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(b)
+}
+
+// Parse lê e valida; campos desconhecidos são erro pra typo não virar valor zerado em produção.
+func Parse(b []byte) (*Ruleset, error) {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	var rs Ruleset
+	if err := dec.Decode(&rs); err != nil {
+		return nil, fmt.Errorf("ruleset: %w", err)
+	}
+	if err := rs.Validate(); err != nil {
+		return nil, err
+	}
+	return &rs, nil
+}
+
+func (rs *Ruleset) Validate() error {
+	if rs.Version == "" {
+		return errors.New("ruleset: version is required")
+	}
+	if rs.XPPerMinute <= 0 {
+		return errors.New("ruleset: xpPerMinute must be > 0")
+	}
+	if rs.GoldRatio < 0 {
+		return errors.New("ruleset: goldRatio must be >= 0")
+	}
+	if rs.QualityMin <= 0 || rs.QualityMax < rs.QualityMin {
+		return errors.New("ruleset: need 0 < qualityMin <= qualityMax")
+	}
+	if !isChance(rs.PartialCredit) {
+		return errors.New("ruleset: partialCredit must be between 0 and 1")
+	}
+	for _, r := range rank.Order {
+		if !rank.IsDungeon(r) {
+			continue
+		}
+		if m, ok := rs.RankMultipliers[r]; !ok || m <= 0 {
+			return fmt.Errorf("ruleset: rankMultipliers.%s must be > 0", r)
+		}
+	}
+	for r := range rs.RankMultipliers {
+		if !rank.IsDungeon(r) {
+			return fmt.Errorf("ruleset: rankMultipliers has unknown rank %q", r)
+		}
+	}
+	for r, m := range rs.MaxPauseMinutes {
+		if !rank.IsDungeon(r) {
+			return fmt.Errorf("ruleset: maxPauseMinutes has unknown rank %q", r)
+		}
+		if m < 0 {
+			return fmt.Errorf("ruleset: maxPauseMinutes.%s must be >= 0", r)
+		}
+	}
+	return rs.Loot.validate()
+}
+
+func (l *Loot) validate() error {
+	if !isChance(l.CritChance) || !isChance(l.BonusGoldChance) {
+		return errors.New("ruleset: loot chances must be between 0 and 1")
+	}
+	if l.CritChance > 0 && l.CritMultiplier < 1 {
+		return errors.New("ruleset: loot.critMultiplier must be >= 1")
+	}
+	if l.BonusGoldMin < 0 || l.BonusGoldMax < l.BonusGoldMin {
+		return errors.New("ruleset: need 0 <= loot.bonusGoldMin <= loot.bonusGoldMax")
+	}
+	for r, t := range l.Tables {
+		if !rank.IsDungeon(r) {
+			return fmt.Errorf("ruleset: loot.tables has unknown rank %q", r)
+		}
+		if !isChance(t.DropChance) {
+			return fmt.Errorf("ruleset: loot.tables.%s.dropChance must be between 0 and 1", r)
+		}
+		if t.DropChance > 0 && len(t.Drops) == 0 {
+			return fmt.Errorf("ruleset: loot.tables.%s has dropChance but no drops", r)
+		}
+		for _, d := range t.Drops {
+			if d.Item == "" || d.Weight <= 0 {
+				return fmt.Errorf("ruleset: loot.tables.%s needs item and weight > 0 on every drop", r)
+			}
+		}
+	}
+	return nil
+}
+
+// ValidateItems confere os itens de drop contra o catálogo (ids da tabela items). Item fora dele
+// nunca chegaria no inventário de ninguém, então o ruleset inteiro é recusado.
+func (rs *Ruleset) ValidateItems(catalog map[string]bool) error {
+	seen := map[string]bool{}
+	var unknown []string
+	for _, t := range rs.Loot.Tables {
+		for _, d := range t.Drops {
+			if !catalog[d.Item] && !seen[d.Item] {
+				seen[d.Item] = true
+				unknown = append(unknown, d.Item)
+			}
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return fmt.Errorf("ruleset: loot drops reference unknown items: %s", strings.Join(unknown, ", "))
+	}
+	return nil
+}
+
+func isChance(p float64) bool {
+	return p >= 0 && p <= 1
+}
+
+// Holder guarda o ruleset em uso e deixa trocar em runtime (SIGHUP) sem lock: cada request pega
+// um ponteiro com Current e usa ele do começo ao fim.
+type Holder struct {
+	cur atomic.Pointer[Ruleset]
+}
+
+func NewHolder(rs *Ruleset) *Holder {
+	h := &Holder{}
+	h.cur.Store(rs)
+	return h
+}
+
+func (h *Holder) Current() *Ruleset {
+	return h.cur.Load()
+}
+
+func (h *Holder) Swap(rs *Ruleset) (old *Ruleset) {
+	return h.cur.Swap(rs)
+}
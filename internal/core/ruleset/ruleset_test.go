@@ -0,0 +1,26 @@
+package ruleset
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateItems(t *testing.T) {
+	rs := Default()
+	catalog := map[string]bool{"xp_potion": true, "streak_shield": true, "rank_key": true}
+	if err := rs.ValidateItems(catalog); err != nil {
+		t.Fatalf("default ruleset against the seeded catalog: %v", err)
+	}
+
+	delete(catalog, "rank_key")
+	err := rs.ValidateItems(catalog)
+	if err == nil || !strings.HasSuffix(err.Error(), "unknown items: rank_key") {
+		t.Fatalf("err = %v, want rank_key reported once", err)
+	}
+
+	rs.Loot.Tables["E"] = LootTable{DropChance: 1, Drops: []LootDrop{{Item: "mana_crystal", Weight: 1}}}
+	err = rs.ValidateItems(catalog)
+	if err == nil || !strings.HasSuffix(err.Error(), "unknown items: mana_crystal, rank_key") {
+		t.Fatalf("err = %v, want both items sorted", err)
+	}
+}
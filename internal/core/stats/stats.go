@@ -0,0 +1,65 @@
+package stats
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+)
+
+const (
+	// Base é o valor de cada stat num hunter recém-despertado (e depois de um respec).
+	Base = 1
+	// RespecGoldPerLevel: o respec custa isso vezes o nível atual.
+	RespecGoldPerLevel = 50
+	// MaxAllocation é o teto de pontos numa distribuição só; bem acima do que a curva dá, e longe
+	// de estourar o int somando os stats.
+	MaxAllocation = 1_000_000
+)
+
+// Names são os stats que existem; qualquer outra chave é rejeitada.
+var Names = []string{"focus", "discipline", "energy", "creativity"}
+
+func BaseStats() map[string]int {
+	m := make(map[string]int, len(Names))
+	for _, n := range Names {
+		m[n] = Base
+	}
+	return m
+}
+
+// ValidateAllocation confere uma distribuição de pontos e devolve o total gasto.
+func ValidateAllocation(alloc map[string]int) (int, error) {
+	total := 0
+	for name, pts := range alloc {
+		if !slices.Contains(Names, name) {
+			return 0, fmt.Errorf("unknown stat: %s", name)
+		}
+		if pts < 0 {
+			return 0, fmt.Errorf("points for %s must be >= 0", name)
+		}
+		// checa antes de somar: valores enormes dariam a volta no int e passariam como poucos pontos
+		if pts > MaxAllocation-total {
+			return 0, fmt.Errorf("cannot allocate more than %d points", MaxAllocation)
+		}
+		total += pts
+	}
+	if total == 0 {
+		return 0, errors.New("no points to allocate")
+	}
+	return total, nil
+}
+
+// Spent é quanto já foi distribuído acima da base, ou seja, o que um respec devolve.
+func Spent(current map[string]int) int {
+	n := 0
+	for _, name := range Names {
+		if v := current[name]; v > Base {
+			n += v - Base
+		}
+	}
+	return n
+}
+
+func RespecCost(level int) int64 {
+	return RespecGoldPerLevel * int64(level)
+}
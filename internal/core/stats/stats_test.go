@@ -0,0 +1,51 @@
+package stats
+
+import (
+	"math"
+	"testing"
+)
+
+func TestValidateAllocation(t *testing.T) {
+	cases := []struct {
+		name    string
+		alloc   map[string]int
+		total   int
+		wantErr bool
+	}{
+		{"single stat", map[string]int{"focus": 2}, 2, false},
+		{"several stats", map[string]int{"focus": 2, "energy": 1, "creativity": 3}, 6, false},
+		{"zero on one stat", map[string]int{"focus": 0, "discipline": 1}, 1, false},
+		{"at the cap", map[string]int{"focus": MaxAllocation}, MaxAllocation, false},
+		{"empty", map[string]int{}, 0, true},
+		{"all zero", map[string]int{"focus": 0, "energy": 0}, 0, true},
+		{"negative", map[string]int{"focus": 3, "energy": -1}, 0, true},
+		{"unknown stat", map[string]int{"strength": 1}, 0, true},
+		{"above the cap", map[string]int{"focus": MaxAllocation + 1}, 0, true},
+		{"sum above the cap", map[string]int{"focus": MaxAllocation, "energy": 1}, 0, true},
+		{"overflow wraps to a small total", map[string]int{
+			"focus": math.MaxInt - 1, "discipline": math.MaxInt - 1, "energy": 5,
+		}, 0, true},
+		{"max int", map[string]int{"focus": math.MaxInt}, 0, true},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			total, err := ValidateAllocation(c.alloc)
+			if (err != nil) != c.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, c.wantErr)
+			}
+			if total != c.total {
+				t.Errorf("total = %d, want %d", total, c.total)
+			}
+		})
+	}
+}
+
+func TestSpent(t *testing.T) {
+	if n := Spent(BaseStats()); n != 0 {
+		t.Errorf("Spent(base) = %d, want 0", n)
+	}
+	cur := map[string]int{"focus": 4, "discipline": 1, "energy": 2, "creativity": 0}
+	if n := Spent(cur); n != 4 {
+		t.Errorf("Spent = %d, want 4", n)
+	}
+}
@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gabrieldemesio/solo-leveling-go-mvp-v2/internal/httpx/middleware"
+	"github.com/gabrieldemesio/solo-leveling-go-mvp-v2/internal/mail"
+	"github.com/gabrieldemesio/solo-leveling-go-mvp-v2/internal/store"
+	"github.com/google/uuid"
+)
+
+// accountDeletionGrace é o prazo pra desistir da exclusão antes do purge apagar os dados.
+const accountDeletionGrace = 30 * 24 * time.Hour
+
+// DeleteAccount agenda a exclusão da conta depois de conferir a senha. Todas as sessões caem na hora.
+func (h *AuthHandler) DeleteAccount(w http.ResponseWriter, r *http.Request) {
+	uid, ok := middleware.UserIDFromContext(r)
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	var in struct {
+		Password string `json:"password"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil || in.Password == "" {
+		http.Error(w, "invalid body", http.StatusBadRequest)
+		return
+	}
+	u, err := store.GetUserByID(r.Context(), h.db, uuid.MustParse(uid))
+	if err != nil {
+		http.Error(w, "Not Found", http.StatusNotFound)
+		return
+	}
+	if u.PassHash == "" {
+		// conta criada via OIDC: define uma senha por /v1/auth/forgot antes de excluir
+		http.Error(w, "account has no password set", http.StatusConflict)
+		return
+	}
+	if ok, err := h.hasher.Verify(in.Password, u.PassHash); err != nil || !ok {
+		http.Error(w, "invalid credentials", http.StatusUnauthorized)
+		return
+	}
+	deleteAfter := time.Now().Add(accountDeletionGrace)
+	if err := store.ScheduleUserDeletion(r.Context(), h.db, u.ID, deleteAfter); err != nil {
+		http.Error(w, "failed to delete account", http.StatusInternalServerError)
+		return
+	}
+	e := store.AuditEvent{
+		ID:        uuid.New(),
+		UserID:    &u.ID,
+		Action:    "account.deletion_scheduled",
+		IP:        clientIP(r),
+		Detail:    map[string]any{"deleteAfter": deleteAfter},
+		CreatedAt: time.Now(),
+	}
+	if err := store.CreateAuditEvent(r.Context(), h.db, &e); err != nil {
+		log.Println("audit account deletion: ", err)
+	}
+	if err := h.mailer.Send(r.Context(), mail.Message{
+		To:      u.Email,
+		Subject: "Exclusão de conta agendada",
+		Body: "Sua conta será excluída em " + deleteAfter.Format("02/01/2006") + ".\n\n" +
+			"Mudou de ideia? Até lá é só restaurar a conta com seu e-mail e senha em " + h.publicURL + "/v1/auth/restore.\n",
+	}); err != nil {
+		log.Println("send account deletion email: ", err)
+	}
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(map[string]any{"deleteAfter": deleteAfter})
+}
+
+// Restore cancela a exclusão agendada. Não emite tokens: depois disso o cliente faz login normal (com MFA, se tiver).
+func (h *AuthHandler) Restore(w http.ResponseWriter, r *http.Request) {
+	var in struct {
+		Email    string `json:"email"`
+		Password string `json:"password"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		http.Error(w, "invalid body", http.StatusBadRequest)
+		return
+	}
+	ip := clientIP(r)
+	if h.rejectIfThrottled(w, r, accountKey(in.Email), ipKey(ip)) {
+		return
+	}
+	u, err := store.GetUserByEmail(r.Context(), h.db, in.Email)
+	if err != nil {
+		h.recordLoginFailure(r.Context(), in.Email, ip, nil)
+		http.Error(w, "invalid credentials", http.StatusUnauthorized)
+		return
+	}
+	if ok, err := h.hasher.Verify(in.Password, u.PassHash); err != nil || !ok {
+		h.recordLoginFailure(r.Context(), in.Email, ip, &u.ID)
+		http.Error(w, "invalid credentials", http.StatusUnauthorized)
+		return
+	}
+	restored, err := store.RestoreUser(r.Context(), h.db, u.ID)
+	if err != nil {
+		http.Error(w, "failed to restore account", http.StatusInternalServerError)
+		return
+	}
+	if !restored {
+		http.Error(w, "account is not scheduled for deletion", http.StatusConflict)
+		return
+	}
+	e := store.AuditEvent{
+		ID:        uuid.New(),
+		UserID:    &u.ID,
+		Action:    "account.restored",
+		IP:        ip,
+		CreatedAt: time.Now(),
+	}
+	if err := store.CreateAuditEvent(r.Context(), h.db, &e); err != nil {
+		log.Println("audit account restore: ", err)
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
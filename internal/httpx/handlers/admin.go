@@ -0,0 +1,291 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gabrieldemesio/solo-leveling-go-mvp-v2/internal/core/leveling"
+	"github.com/gabrieldemesio/solo-leveling-go-mvp-v2/internal/httpx/middleware"
+	"github.com/gabrieldemesio/solo-leveling-go-mvp-v2/internal/store"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	pageDefaultLimit = 50
+	pageMaxLimit     = 200
+)
+
+// AdminHandler atende /v1/admin. Toda ação que muda um usuário exige reason e vira um audit_event
+// com o admin que fez (actor).
+type AdminHandler struct {
+	db    *pgxpool.Pool
+	curve leveling.Curve
+}
+
+func NewAdminHandler(db *pgxpool.Pool, curve leveling.Curve) *AdminHandler {
+	return &AdminHandler{db: db, curve: curve}
+}
+
+// adminEvent monta o evento de auditoria de uma ação do admin sobre o usuário target.
+func adminEvent(r *http.Request, target uuid.UUID, action, reason string, detail map[string]any) *store.AuditEvent {
+	actor, _ := middleware.UserIDFromContext(r)
+	if detail == nil {
+		detail = map[string]any{}
+	}
+	detail["actor"] = actor
+	detail["reason"] = reason
+	return &store.AuditEvent{
+		ID:        uuid.New(),
+		UserID:    &target,
+		Action:    action,
+		IP:        clientIP(r),
+		Detail:    detail,
+		CreatedAt: time.Now(),
+	}
+}
+
+// targetUserID lê o {id} da rota; responde 400 se não for um uuid.
+func targetUserID(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "invalid id", http.StatusBadRequest)
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+// pageParams lê limit/offset da query; responde 400 se forem inválidos.
+func pageParams(w http.ResponseWriter, r *http.Request) (limit, offset int, ok bool) {
+	q := r.URL.Query()
+	limit = pageDefaultLimit
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			http.Error(w, "invalid limit", http.StatusBadRequest)
+			return 0, 0, false
+		}
+		limit = min(n, pageMaxLimit)
+	}
+	if v := q.Get("offset"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			http.Error(w, "invalid offset", http.StatusBadRequest)
+			return 0, 0, false
+		}
+		offset = n
+	}
+	return limit, offset, true
+}
+
+func (h *AdminHandler) SearchUsers(w http.ResponseWriter, r *http.Request) {
+	limit, offset, ok := pageParams(w, r)
+	if !ok {
+		return
+	}
+	users, err := store.SearchUsers(r.Context(), h.db, strings.TrimSpace(r.URL.Query().Get("q")), limit, offset)
+	if err != nil {
+		http.Error(w, "failed to search users", http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(users)
+}
+
+func (h *AdminHandler) GetUser(w http.ResponseWriter, r *http.Request) {
+	id, ok := targetUserID(w, r)
+	if !ok {
+		return
+	}
+	u, err := store.GetUserByID(r.Context(), h.db, id)
+	if err != nil {
+		http.Error(w, "Not Found", http.StatusNotFound)
+		return
+	}
+	json.NewEncoder(w).Encode(u)
+}
+
+// Ledger mostra de onde veio e pra onde foi o XP e o gold do usuário.
+func (h *AdminHandler) Ledger(w http.ResponseWriter, r *http.Request) {
+	id, ok := targetUserID(w, r)
+	if !ok {
+		return
+	}
+	limit, offset, ok := pageParams(w, r)
+	if !ok {
+		return
+	}
+	entries, err := store.ListLedgerEntries(r.Context(), h.db, id, limit, offset)
+	if err != nil {
+		http.Error(w, "failed to list ledger", http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(entries)
+}
+
+func (h *AdminHandler) Ban(w http.ResponseWriter, r *http.Request) {
+	id, ok := targetUserID(w, r)
+	if !ok {
+		return
+	}
+	var in struct {
+		Reason string `json:"reason"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil || strings.TrimSpace(in.Reason) == "" {
+		http.Error(w, "reason is required", http.StatusBadRequest)
+		return
+	}
+	if actor, _ := middleware.UserIDFromContext(r); actor == id.String() {
+		http.Error(w, "cannot ban yourself", http.StatusConflict)
+		return
+	}
+	err := store.BanUser(r.Context(), h.db, id, in.Reason, adminEvent(r, id, "admin.ban", in.Reason, nil))
+	h.respondMutation(w, err)
+}
+
+func (h *AdminHandler) Unban(w http.ResponseWriter, r *http.Request) {
+	id, ok := targetUserID(w, r)
+	if !ok {
+		return
+	}
+	var in struct {
+		Reason string `json:"reason"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil || strings.TrimSpace(in.Reason) == "" {
+		http.Error(w, "reason is required", http.StatusBadRequest)
+		return
+	}
+	err := store.UnbanUser(r.Context(), h.db, id, adminEvent(r, id, "admin.unban", in.Reason, nil))
+	h.respondMutation(w, err)
+}
+
+// Adjust corrige xp/gold (deltas) e opcionalmente o streak, ex.: streak quebrado por bug.
+func (h *AdminHandler) Adjust(w http.ResponseWriter, r *http.Request) {
+	id, ok := targetUserID(w, r)
+	if !ok {
+		return
+	}
+	var in struct {
+		XP     int64  `json:"xp"`
+		Gold   int64  `json:"gold"`
+		Streak *int   `json:"streak"`
+		Reason string `json:"reason"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		http.Error(w, "invalid body", http.StatusBadRequest)
+		return
+	}
+	if strings.TrimSpace(in.Reason) == "" {
+		http.Error(w, "reason is required", http.StatusBadRequest)
+		return
+	}
+	if in.XP == 0 && in.Gold == 0 && in.Streak == nil {
+		http.Error(w, "nothing to adjust", http.StatusBadRequest)
+		return
+	}
+	if in.Streak != nil && *in.Streak < 0 {
+		http.Error(w, "streak must be >= 0", http.StatusBadRequest)
+		return
+	}
+	detail := map[string]any{"xp": in.XP, "gold": in.Gold}
+	if in.Streak != nil {
+		detail["streak"] = *in.Streak
+	}
+	adj := store.UserAdjustment{XP: in.XP, Gold: in.Gold, Streak: in.Streak}
+	u, err := store.AdjustUser(r.Context(), h.db, id, adj, h.curve, adminEvent(r, id, "admin.adjust", in.Reason, detail))
+	switch {
+	case err == nil:
+	case errors.Is(err, pgx.ErrNoRows):
+		http.Error(w, "Not Found", http.StatusNotFound)
+		return
+	case errors.Is(err, store.ErrNegativeBalance):
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	default:
+		log.Println("admin adjust: ", err)
+		http.Error(w, "failed to adjust user", http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(u)
+}
+
+func (h *AdminHandler) SetRole(w http.ResponseWriter, r *http.Request) {
+	id, ok := targetUserID(w, r)
+	if !ok {
+		return
+	}
+	var in struct {
+		Role   string `json:"role"`
+		Reason string `json:"reason"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		http.Error(w, "invalid body", http.StatusBadRequest)
+		return
+	}
+	if in.Role != store.RoleUser && in.Role != store.RoleAdmin {
+		http.Error(w, "invalid role", http.StatusBadRequest)
+		return
+	}
+	if strings.TrimSpace(in.Reason) == "" {
+		http.Error(w, "reason is required", http.StatusBadRequest)
+		return
+	}
+	if actor, _ := middleware.UserIDFromContext(r); actor == id.String() {
+		// evita o último admin se rebaixar sem querer
+		http.Error(w, "cannot change your own role", http.StatusConflict)
+		return
+	}
+	err := store.SetUserRole(r.Context(), h.db, id, in.Role,
+		adminEvent(r, id, "admin.role", in.Reason, map[string]any{"role": in.Role}))
+	h.respondMutation(w, err)
+}
+
+func (h *AdminHandler) respondMutation(w http.ResponseWriter, err error) {
+	switch {
+	case err == nil:
+		w.WriteHeader(http.StatusNoContent)
+	case errors.Is(err, pgx.ErrNoRows):
+		http.Error(w, "Not Found", http.StatusNotFound)
+	default:
+		log.Println("admin: ", err)
+		http.Error(w, "failed to update user", http.StatusInternalServerError)
+	}
+}
+
+// ForcePasswordReset derruba as sessões do usuário, bloqueia o login por senha e manda o link de reset.
+// Fica no AuthHandler porque reaproveita o fluxo de e-mail de /v1/auth/forgot.
+func (h *AuthHandler) ForcePasswordReset(w http.ResponseWriter, r *http.Request) {
+	id, ok := targetUserID(w, r)
+	if !ok {
+		return
+	}
+	var in struct {
+		Reason string `json:"reason"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil || strings.TrimSpace(in.Reason) == "" {
+		http.Error(w, "reason is required", http.StatusBadRequest)
+		return
+	}
+	u, err := store.GetUserByID(r.Context(), h.db, id)
+	if err != nil {
+		http.Error(w, "Not Found", http.StatusNotFound)
+		return
+	}
+	if err := store.RequirePasswordReset(r.Context(), h.db, u.ID,
+		adminEvent(r, u.ID, "admin.password_reset", in.Reason, nil)); err != nil {
+		http.Error(w, "failed to reset password", http.StatusInternalServerError)
+		return
+	}
+	if err := h.sendPasswordResetEmail(r.Context(), u,
+		"Por segurança, sua senha precisa ser redefinida antes do próximo login.",
+		"Em caso de dúvida, fale com o suporte."); err != nil {
+		log.Println("send forced password reset email: ", err)
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
@@ -1,26 +1,74 @@
 package handlers
 
 import (
-	"crypto/rand"
-	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"log"
 	"net/http"
+	netmail "net/mail"
+	"strings"
 	"time"
 
+	"github.com/gabrieldemesio/solo-leveling-go-mvp-v2/internal/auth/oidc"
+	"github.com/gabrieldemesio/solo-leveling-go-mvp-v2/internal/auth/password"
+	"github.com/gabrieldemesio/solo-leveling-go-mvp-v2/internal/auth/signing"
+	"github.com/gabrieldemesio/solo-leveling-go-mvp-v2/internal/core/rank"
+	"github.com/gabrieldemesio/solo-leveling-go-mvp-v2/internal/core/stats"
+	"github.com/gabrieldemesio/solo-leveling-go-mvp-v2/internal/httpx/middleware"
+	"github.com/gabrieldemesio/solo-leveling-go-mvp-v2/internal/mail"
 	"github.com/gabrieldemesio/solo-leveling-go-mvp-v2/internal/store"
-	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
-	"golang.org/x/crypto/bcrypt"
 )
 
+type AuthOptions struct {
+	Keys   *signing.KeySet
+	Mailer mail.Mailer
+	// PublicURL é a base dos links enviados por e-mail.
+	PublicURL string
+	// PasswordResetURL é a página do frontend que recebe ?token= e chama POST /v1/auth/reset;
+	// vazio usa a página servida pela própria API em GET /v1/auth/reset.
+	PasswordResetURL string
+	Hasher           password.Hasher
+	Policy           *password.Policy
+	// OIDC é opcional; sem ele as rotas /v1/auth/oidc nem são montadas.
+	OIDC *oidc.Provider
+	// OIDCPostLoginRedirect recebe os tokens no fragment depois do callback; vazio responde JSON.
+	OIDCPostLoginRedirect string
+}
+
 type AuthHandler struct {
 	db        *pgxpool.Pool
-	jwtSecret []byte
+	keys      *signing.KeySet
+	mailer    mail.Mailer
+	publicURL string
+	resetURL  string
+	hasher    password.Hasher
+	policy    *password.Policy
+
+	oidc                  *oidc.Provider
+	oidcPostLoginRedirect string
 }
 
-func NewAuthHandler(db *pgxpool.Pool, jwtSecret []byte) *AuthHandler {
-	return &AuthHandler{db: db, jwtSecret: jwtSecret}
+func NewAuthHandler(db *pgxpool.Pool, opts AuthOptions) *AuthHandler {
+	publicURL := strings.TrimRight(opts.PublicURL, "/")
+	resetURL := opts.PasswordResetURL
+	if resetURL == "" {
+		resetURL = publicURL + "/v1/auth/reset"
+	}
+	return &AuthHandler{
+		db:        db,
+		keys:      opts.Keys,
+		mailer:    opts.Mailer,
+		publicURL: publicURL,
+		resetURL:  resetURL,
+		hasher:    opts.Hasher,
+		policy:    opts.Policy,
+
+		oidc:                  opts.OIDC,
+		oidcPostLoginRedirect: opts.OIDCPostLoginRedirect,
+	}
 }
 func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 	var in struct {
@@ -31,27 +79,49 @@ func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "invalid body", http.StatusBadRequest)
 		return
 	}
-	hash, _ := bcrypt.GenerateFromPassword([]byte(in.Password), bcrypt.DefaultCost)
-	id := uuid.New()
-	now := time.Now()
-	u := store.User{
-		ID:        id,
-		Email:     in.Email,
-		PassHash:  string(hash),
-		Level:     1,
-		XP:        0,
-		Gold:      0,
-		Stats:     map[string]int{"focus": 1, "discipline": 1, "energy": 1, "creativity": 1},
-		Streak:    0,
-		CreatedAt: now,
+	in.Email = strings.TrimSpace(in.Email)
+	if addr, err := netmail.ParseAddress(in.Email); err != nil || addr.Address != in.Email {
+		http.Error(w, "invalid email", http.StatusBadRequest)
+		return
 	}
+	if err := h.policy.Check(in.Password); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	hash, err := h.hasher.Hash(in.Password)
+	if err != nil {
+		http.Error(w, "failed to create user", http.StatusInternalServerError)
+		return
+	}
+	u := newUser(in.Email, hash)
 	if err := store.CreateUser(r.Context(), h.db, &u); err != nil {
 		http.Error(w, "failed to create user", http.StatusConflict)
 		return
 	}
+	if err := h.sendVerificationEmail(r.Context(), &u); err != nil {
+		// a conta já existe; dá pra pedir outro link depois em /v1/auth/verify/resend
+		log.Println("send verification email: ", err)
+	}
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(map[string]any{"id": u.ID})
 }
+
+// newUser monta um hunter recém-despertado: nível 1, tudo zerado e stats base.
+func newUser(email, passHash string) store.User {
+	return store.User{
+		ID:         uuid.New(),
+		Email:      email,
+		PassHash:   passHash,
+		Level:      1,
+		XP:         0,
+		Gold:       0,
+		Stats:      stats.BaseStats(),
+		Streak:     0,
+		Role:       store.RoleUser,
+		HunterRank: rank.E,
+		CreatedAt:  time.Now(),
+	}
+}
 func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 	var in struct {
 		Email    string `json:"email"`
@@ -61,33 +131,147 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "invalid body", http.StatusBadRequest)
 		return
 	}
+	ip := clientIP(r)
+	if h.rejectIfThrottled(w, r, accountKey(in.Email), ipKey(ip)) {
+		return
+	}
 	u, err := store.GetUserByEmail(r.Context(), h.db, in.Email)
 	if err != nil {
+		h.recordLoginFailure(r.Context(), in.Email, ip, nil)
 		http.Error(w, "invalid credentials", http.StatusUnauthorized)
 		return
 	}
-	if err := bcrypt.CompareHashAndPassword([]byte(u.PassHash), []byte(in.Password)); err != nil {
+	if ok, err := h.hasher.Verify(in.Password, u.PassHash); err != nil || !ok {
+		h.recordLoginFailure(r.Context(), in.Email, ip, &u.ID)
 		http.Error(w, "invalid credentials", http.StatusUnauthorized)
 		return
 	}
+	if err := store.ClearLoginFailures(r.Context(), h.db, accountKey(in.Email)); err != nil {
+		log.Println("clear login failures: ", err)
+	}
+	if u.BannedAt != nil {
+		http.Error(w, "account banned", http.StatusForbidden)
+		return
+	}
+	if u.DeleteAfter != nil {
+		http.Error(w, "account scheduled for deletion, restore it at /v1/auth/restore", http.StatusForbidden)
+		return
+	}
+	if u.PasswordResetRequired {
+		http.Error(w, "password reset required, use /v1/auth/forgot", http.StatusForbidden)
+		return
+	}
+	if h.hasher.NeedsRehash(u.PassHash) {
+		// senha conferiu, aproveita pra migrar o hash (bcrypt legado ou parâmetros antigos)
+		if newHash, err := h.hasher.Hash(in.Password); err == nil {
+			if err := store.UpgradePasswordHash(r.Context(), h.db, u.ID, u.PassHash, newHash); err != nil {
+				log.Println("rehash password: ", err)
+			}
+		}
+	}
+	mfa, err := store.MFAEnabled(r.Context(), h.db, u.ID)
+	if err != nil {
+		http.Error(w, "failed to login", http.StatusInternalServerError)
+		return
+	}
+	if mfa {
+		// senha ok, mas o access token só sai depois do código em /v1/auth/mfa
+		challenge, err := h.signMFAChallenge(r.Context(), u.ID)
+		if err != nil {
+			http.Error(w, "failed to issue token", http.StatusInternalServerError)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{"mfaRequired": true, "mfaToken": challenge})
+		return
+	}
+	tokens, err := h.issueTokens(r, u.ID)
+	if err != nil {
+		http.Error(w, "failed to issue token", http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(tokens)
+}
+func (h *AuthHandler) Refresh(w http.ResponseWriter, r *http.Request) {
+	var in struct {
+		RefreshToken string `json:"refreshToken"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil || in.RefreshToken == "" {
+		http.Error(w, "invalid body", http.StatusBadRequest)
+		return
+	}
+	raw, hash, err := newOpaqueToken()
+	if err != nil {
+		http.Error(w, "failed to issue token", http.StatusInternalServerError)
+		return
+	}
 	now := time.Now()
-	claims := jwt.MapClaims{
-		"iss": "solo-leveling",
-		"sub": 	u.ID.String(),
-		"iat": now.Unix(),
-		"exp": now.Add(24 * time.Hour).Unix(),
-		"rnd": randomNonce(),
-	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	ss, _ := token.SignedString(h.jwtSecret)
-	json.NewEncoder(w).Encode(map[string]any{
-		"accesToken"	: ss,
-	})
+	next := store.RefreshToken{
+		ID:        uuid.New(),
+		TokenHash: hash,
+		ExpiresAt: now.Add(refreshTokenTTL),
+		CreatedAt: now,
+	}
+	jti := uuid.NewString()
+	err = store.RotateRefreshToken(r.Context(), h.db, hashToken(in.RefreshToken), &next, jti)
+	switch {
+	case err == nil:
+	case errors.Is(err, store.ErrRefreshTokenReused):
+		log.Printf("refresh token reuse detected, family %s revoked", next.FamilyID)
+		http.Error(w, "invalid refresh token", http.StatusUnauthorized)
+		return
+	case errors.Is(err, pgx.ErrNoRows), errors.Is(err, store.ErrRefreshTokenRevoked), errors.Is(err, store.ErrRefreshTokenExpired):
+		http.Error(w, "invalid refresh token", http.StatusUnauthorized)
+		return
+	default:
+		http.Error(w, "failed to refresh token", http.StatusInternalServerError)
+		return
+	}
+	access, err := h.signAccessToken(r.Context(), next.UserID, next.FamilyID, jti)
+	if err != nil {
+		http.Error(w, "failed to issue token", http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(newTokenPair(access, raw))
 }
-func randomNonce() string {
-	b := make([]byte, 8)
-	if _, err := rand.Read(b); err != nil {
-		return "0"
+
+// Logout encerra a sessão do token atual (a família de refresh tokens dela vai junto).
+func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	uid, ok := middleware.UserIDFromContext(r)
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	tok, ok := middleware.TokenFromContext(r)
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	userID := uuid.MustParse(uid)
+	if err := store.RevokeAccessToken(r.Context(), h.db, userID, tok.ID, tok.ExpiresAt); err != nil {
+		http.Error(w, "failed to logout", http.StatusInternalServerError)
+		return
+	}
+	if _, err := store.RevokeSession(r.Context(), h.db, userID, tok.SessionID); err != nil {
+		http.Error(w, "failed to logout", http.StatusInternalServerError)
+		return
 	}
-	return hex.EncodeToString(b)
-}
\ No newline at end of file
+	w.WriteHeader(http.StatusNoContent)
+}
+func (h *AuthHandler) LogoutAll(w http.ResponseWriter, r *http.Request) {
+	uid, ok := middleware.UserIDFromContext(r)
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if err := store.RevokeAllUserTokens(r.Context(), h.db, uuid.MustParse(uid)); err != nil {
+		http.Error(w, "failed to logout", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *AuthHandler) JWKS(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	json.NewEncoder(w).Encode(h.keys.JWKS())
+}
@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"errors"
+	"log"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/gabrieldemesio/solo-leveling-go-mvp-v2/internal/httpx/middleware"
+	"github.com/gabrieldemesio/solo-leveling-go-mvp-v2/internal/store"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// Export devolve um zip com um JSON por entidade com tudo que é do usuário.
+// Segredos (hashes de senha/token, segredo TOTP) ficam de fora.
+func (h *MeHandler) Export(w http.ResponseWriter, r *http.Request) {
+	uid, ok := middleware.UserIDFromContext(r)
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	userID := uuid.MustParse(uid)
+	ctx := r.Context()
+
+	u, err := store.GetUserByID(ctx, h.db, userID)
+	if err != nil {
+		http.Error(w, "Not Found", http.StatusNotFound)
+		return
+	}
+	// junta tudo antes de escrever: depois do primeiro byte do zip não dá mais pra responder 500
+	type file struct {
+		name string
+		data any
+	}
+	files := []file{{"user.json", u}}
+	add := func(name string, data any, err error) bool {
+		if err != nil {
+			log.Println("export "+name+": ", err)
+			http.Error(w, "failed to export data", http.StatusInternalServerError)
+			return false
+		}
+		files = append(files, file{name, data})
+		return true
+	}
+	quests, err := store.ListQuestsByUser(ctx, h.db, userID)
+	if !add("quests.json", quests, err) {
+		return
+	}
+	runs, err := store.ListFocusRunsByUser(ctx, h.db, userID)
+	if !add("focus_runs.json", runs, err) {
+		return
+	}
+	sessions, err := store.ListSessions(ctx, h.db, userID)
+	if !add("sessions.json", sessions, err) {
+		return
+	}
+	pats, err := store.ListPersonalAccessTokens(ctx, h.db, userID)
+	if !add("personal_access_tokens.json", pats, err) {
+		return
+	}
+	identities, err := store.ListUserIdentities(ctx, h.db, userID)
+	if !add("identities.json", identities, err) {
+		return
+	}
+	mfa, err := store.GetUserMFA(ctx, h.db, userID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		mfa, err = nil, nil
+	}
+	if !add("mfa.json", mfa, err) {
+		return
+	}
+	levelUps, err := store.ListLevelUpEvents(ctx, h.db, userID)
+	if !add("level_up_events.json", levelUps, err) {
+		return
+	}
+	inventory, err := store.ListInventory(ctx, h.db, userID)
+	if !add("inventory.json", inventory, err) {
+		return
+	}
+	effects, err := store.ListUserEffects(ctx, h.db, userID, false)
+	if !add("item_effects.json", effects, err) {
+		return
+	}
+	shop, err := store.ListShopRewards(ctx, h.db, userID)
+	if !add("shop_rewards.json", shop, err) {
+		return
+	}
+	redemptions, err := store.ListRewardRedemptions(ctx, h.db, userID)
+	if !add("reward_redemptions.json", redemptions, err) {
+		return
+	}
+	ledger, err := store.ListLedgerEntries(ctx, h.db, userID, math.MaxInt32, 0)
+	if !add("ledger.json", ledger, err) {
+		return
+	}
+	events, err := store.ListAuditEventsByUser(ctx, h.db, userID)
+	if !add("audit_events.json", events, err) {
+		return
+	}
+
+	now := time.Now()
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", `attachment; filename="solo-leveling-export-`+now.Format("2006-01-02")+`.zip"`)
+	zw := zip.NewWriter(w)
+	for _, f := range files {
+		fw, err := zw.CreateHeader(&zip.FileHeader{Name: f.name, Method: zip.Deflate, Modified: now})
+		if err != nil {
+			log.Println("export: ", err)
+			return
+		}
+		enc := json.NewEncoder(fw)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(f.data); err != nil {
+			log.Println("export: ", err)
+			return
+		}
+	}
+	if err := zw.Close(); err != nil {
+		log.Println("export: ", err)
+	}
+}
@@ -1,11 +1,19 @@
 package handlers
 
 import (
+	"crypto/rand"
+	"encoding/binary"
 	"encoding/json"
+	"errors"
+	"log"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/gabrieldemesio/solo-leveling-go-mvp-v2/internal/core/battle"
+	"github.com/gabrieldemesio/solo-leveling-go-mvp-v2/internal/core/leveling"
+	"github.com/gabrieldemesio/solo-leveling-go-mvp-v2/internal/core/rank"
+	"github.com/gabrieldemesio/solo-leveling-go-mvp-v2/internal/core/ruleset"
 	"github.com/gabrieldemesio/solo-leveling-go-mvp-v2/internal/httpx/middleware"
 	"github.com/gabrieldemesio/solo-leveling-go-mvp-v2/internal/store"
 	"github.com/go-chi/chi/v5"
@@ -14,12 +22,36 @@ import (
 )
 
 type GateHandler struct {
-	db *pgxpool.Pool
+	db    *pgxpool.Pool
+	curve leveling.Curve
+	rules *ruleset.Holder
 }
 
-func NewGateHandler(db *pgxpool.Pool) *GateHandler {
-	return &GateHandler{db: db}
+func NewGateHandler(db *pgxpool.Pool, curve leveling.Curve, rules *ruleset.Holder) *GateHandler {
+	return &GateHandler{db: db, curve: curve, rules: rules}
 }
+
+// levelUp é o que o cliente precisa pra mostrar o "Level Up!".
+type levelUp struct {
+	From        int   `json:"from"`
+	To          int   `json:"to"`
+	NextLevelXP int64 `json:"nextLevelXp"`
+	StatPoints  int   `json:"statPoints"`
+}
+
+type closeResponse struct {
+	store.FocusRun
+	Rewards battle.Breakdown `json:"rewards"`
+	LevelUp *levelUp         `json:"levelUp,omitempty"`
+	// RankUp vem com o novo rank quando uma reavaliação termina com sucesso.
+	RankUp string `json:"rankUp,omitempty"`
+	// Downgraded indica que o cliente pediu success antes do alvo e o gate fechou como abandono.
+	Downgraded bool `json:"downgraded,omitempty"`
+}
+
+// Open abre um gate. Rank acima do que o hunter pode gasta uma chave de rank ativa; sem chave é
+// recusado, ou rebaixado pro máximo dele com downgrade=true. kind=reassessment abre a reavaliação
+// pro próximo tier.
 func (h *GateHandler) Open(w http.ResponseWriter, r *http.Request) {
 	uid, ok := middleware.UserIDFromContext(r)
 	if !ok {
@@ -27,28 +59,97 @@ func (h *GateHandler) Open(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	var in struct {
-		QuestID *uuid.UUID `json:"questid"`
-		Rank    string     `json:"rank"`
-		Minutes int        `json:"minutes"`
+		QuestID   *uuid.UUID `json:"questid"`
+		Rank      string     `json:"rank"`
+		Minutes   int        `json:"minutes"`
+		Kind      string     `json:"kind"`
+		Downgrade bool       `json:"downgrade"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&in); err != nil || in.Minutes <= 0 {
 		http.Error(w, "invalid body", http.StatusBadRequest)
 		return
 	}
-	if in.Rank == "" {
-		in.Rank = "E"
+	u, err := store.GetUserByID(r.Context(), h.db, uuid.MustParse(uid))
+	if err != nil {
+		http.Error(w, "Not Found", http.StatusNotFound)
+		return
+	}
+	keyTiers := 0
+	switch in.Kind {
+	case "", store.RunKindNormal:
+		in.Kind = store.RunKindNormal
+		if in.Rank == "" {
+			in.Rank = rank.E
+		}
+		if !rank.IsDungeon(in.Rank) {
+			http.Error(w, "invalid rank", http.StatusBadRequest)
+			return
+		}
+		if !rank.CanEnter(u.HunterRank, in.Rank) {
+			// acima do rank: tenta uma chave de rank ativa antes de rebaixar ou recusar
+			keyTiers = rank.Index(in.Rank) - rank.Index(rank.MaxDungeon(u.HunterRank))
+		}
+	case store.RunKindReassessment:
+		next, ok := rank.Next(u.HunterRank)
+		if !ok {
+			http.Error(w, "already at the highest rank", http.StatusConflict)
+			return
+		}
+		clears, err := store.CountClears(r.Context(), h.db, u.ID, rank.MaxDungeon(u.HunterRank))
+		if err != nil {
+			http.Error(w, "failed to create run", http.StatusInternalServerError)
+			return
+		}
+		if !rank.Eligible(u.HunterRank, u.Level, clears) {
+			http.Error(w, "not eligible for reassessment", http.StatusForbidden)
+			return
+		}
+		if in.Minutes < rank.ReassessmentMinMinutes {
+			http.Error(w, "reassessment needs at least "+strconv.Itoa(rank.ReassessmentMinMinutes)+" minutes", http.StatusBadRequest)
+			return
+		}
+		in.Rank = rank.ReassessmentDungeon(next)
+	default:
+		http.Error(w, "invalid kind", http.StatusBadRequest)
+		return
+	}
+	var seed [8]byte
+	if _, err := rand.Read(seed[:]); err != nil {
+		http.Error(w, "failed to create run", http.StatusInternalServerError)
+		return
 	}
 	run := store.FocusRun{
-		ID:            uuid.New(),
-		UserID:        uuid.MustParse(uid),
-		QuestID:       in.QuestID,
-		DungeonRank:   in.Rank,
-		StartAt:       time.Now(),
-		TargetMinutes: in.Minutes,
-		XPEarned:      0,
-		GoldEarned:    0,
-	}
-	if err := store.CreateFocusRun(r.Context(), h.db, &run); err != nil {
+		ID:             uuid.New(),
+		UserID:         u.ID,
+		QuestID:        in.QuestID,
+		DungeonRank:    in.Rank,
+		Kind:           in.Kind,
+		StartAt:        time.Now(),
+		TargetMinutes:  in.Minutes,
+		XPEarned:       0,
+		GoldEarned:     0,
+		RulesetVersion: h.rules.Current().Version,
+		LootSeed:       int64(binary.BigEndian.Uint64(seed[:])),
+		Pauses:         []store.FocusRunPause{},
+	}
+	if keyTiers > 0 {
+		err = store.CreateFocusRunWithRankKey(r.Context(), h.db, &run, keyTiers)
+		if errors.Is(err, store.ErrNoRankKey) {
+			if !in.Downgrade {
+				http.Error(w, "gate rank above hunter rank "+u.HunterRank, http.StatusForbidden)
+				return
+			}
+			run.DungeonRank = rank.MaxDungeon(u.HunterRank)
+			err = store.CreateFocusRun(r.Context(), h.db, &run)
+		}
+	} else {
+		err = store.CreateFocusRun(r.Context(), h.db, &run)
+	}
+	if errors.Is(err, store.ErrGateOpen) {
+		http.Error(w, "another gate is already open", http.StatusConflict)
+		return
+	}
+	if err != nil {
 		http.Error(w, "failed to create run", http.StatusBadRequest)
 		return
 	}
@@ -74,6 +175,10 @@ func (h *GateHandler) Close(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "invalid body", http.StatusBadRequest)
 		return
 	}
+	if in.Result != store.RunResultSuccess && in.Result != store.RunResultAbandon {
+		http.Error(w, "result must be success or abandon", http.StatusBadRequest)
+		return
+	}
 	run, err := store.GetFocusRunByID(r.Context(), h.db, runID)
 	if err != nil || run.UserID.String() != uid {
 		http.Error(w, "Not Found", http.StatusNotFound)
@@ -86,28 +191,193 @@ func (h *GateHandler) Close(w http.ResponseWriter, r *http.Request) {
 	if in.Quality <= 0 {
 		in.Quality = 1.0
 	}
-	mult := battle.RankMultiplier(run.DungeonRank)
-	questWeight := 1
+	u, err := store.GetUserByID(r.Context(), h.db, run.UserID)
+	if err != nil {
+		http.Error(w, "Not Found", http.StatusNotFound)
+		return
+	}
+	// um ruleset só do começo ao fim, mesmo se um SIGHUP trocar no meio
+	rules := h.rules.Current()
+	// o tempo é do relógio do servidor, sem as pausas: success antes do alvo vira abandono com crédito parcial
+	now := time.Now()
+	elapsed := battle.Elapsed(run.StartAt, now, store.PausedTotal(run.Pauses, now), battle.MaxPause(rules, run.DungeonRank))
+	downgraded := false
+	if in.Result == store.RunResultSuccess && !battle.Reached(run.TargetMinutes, elapsed) {
+		in.Result, downgraded = store.RunResultAbandon, true
+	}
+	g := battle.Gate{
+		Minutes:        run.TargetMinutes,
+		Elapsed:        elapsed,
+		RankMultiplier: battle.RankMultiplier(rules, run.DungeonRank),
+		QuestWeight:    1,
+		Quality:        in.Quality,
+		Success:        in.Result == store.RunResultSuccess,
+	}
 	if run.QuestID != nil {
-		if w, err := store.GetQuestWeight(r.Context(), h.db, *run.QuestID); err == nil {
-			questWeight = w
+		if q, err := store.GetQuestByID(r.Context(), h.db, *run.QuestID); err == nil {
+			g.QuestWeight, g.QuestTags = q.Weight, q.Tags
 		}
 	}
 
-	xp, gold := battle.ComputeRewards(run.TargetMinutes, mult, questWeight, in.Quality)
-	now := time.Now()
+	rewards := battle.Resolve(rules, g, u.Stats)
+	var drops []string
+	if g.Success {
+		// o seed foi sorteado no Open: o resultado do loot já estava decidido antes do gate começar
+		loot := battle.RollLoot(rules, run.DungeonRank, run.LootSeed, rewards.XP, rewards.Gold)
+		rewards.AddLoot(loot)
+		run.Loot, _ = json.Marshal(loot)
+		for _, d := range loot.Drops {
+			drops = append(drops, d.Item)
+		}
+	}
 	run.EndAt = &now
 	run.Result = &in.Result
-	run.XPEarned = xp
-	run.GoldEarned = gold
-	if err := store.FinishFocusRun(r.Context(), h.db, &run); err != nil {
-		http.Error(w, "failed to update run", http.StatusBadRequest)
-		return
+	run.XPEarned = rewards.XP
+	run.GoldEarned = rewards.Gold
+	run.RulesetVersion = rules.Version
+	var ev *store.LevelUpEvent
+	var rankUp *store.RankUp
+	if run.Kind == store.RunKindReassessment && g.Success {
+		if next, ok := rank.Next(u.HunterRank); ok {
+			rankUp = &store.RankUp{From: u.HunterRank, To: next}
+		}
 	}
-	if in.Result == "success" {
-		_ = store.AddXPAndGold(r.Context(), h.db, run.UserID, xp, gold, true)
+	if g.Success || rewards.XP > 0 {
+		// abandono com XP salvo pela discipline também passa aqui: success=false quebra o streak do mesmo jeito
+		ev, err = store.AddXPAndGold(r.Context(), h.db, run.UserID, store.GateReward{
+			Run:     &run,
+			XP:      rewards.XP,
+			Gold:    rewards.Gold,
+			Success: g.Success,
+			Drops:   drops,
+			Boost: func(e store.UserEffect, xp, gold int64) (int64, int64) {
+				rewards.ApplyXPMultiplier(e.Power)
+				return rewards.XP, rewards.Gold
+			},
+			RankUp: rankUp,
+		}, h.curve)
 	} else {
-		_ = store.UpdateStreak(r.Context(), h.db, run.UserID, false)
+		err = store.AbandonFocusRun(r.Context(), h.db, &run)
 	}
-	json.NewEncoder(w).Encode(run)
+	switch {
+	case err == nil:
+	case errors.Is(err, store.ErrRunClosed):
+		// fechado no meio do caminho por outro request ou pelo worker de expiração
+		http.Error(w, "already closed", http.StatusBadRequest)
+		return
+	default:
+		// nada foi gravado: o gate continua aberto pro cliente tentar fechar de novo
+		log.Println("close gate: ", err)
+		http.Error(w, "failed to update run", http.StatusInternalServerError)
+		return
+	}
+	resp := closeResponse{FocusRun: run, Rewards: rewards, Downgraded: downgraded}
+	if ev != nil {
+		resp.LevelUp = &levelUp{
+			From:        ev.FromLevel,
+			To:          ev.ToLevel,
+			NextLevelXP: h.curve.XPForLevel(ev.ToLevel + 1),
+			StatPoints:  ev.StatPoints,
+		}
+	}
+	if rankUp != nil && rankUp.Promoted {
+		resp.RankUp = rankUp.To
+	}
+	json.NewEncoder(w).Encode(resp)
+}
+
+// runView é o gate com o estado da pausa, pra o cliente mostrar o timer certo.
+type runView struct {
+	store.FocusRun
+	Paused           bool  `json:"paused"`
+	ElapsedSeconds   int64 `json:"elapsedSeconds"`
+	PauseLeftSeconds int64 `json:"pauseLeftSeconds"`
+}
+
+func (h *GateHandler) view(run store.FocusRun) runView {
+	now := time.Now()
+	if run.EndAt != nil {
+		now = *run.EndAt
+	}
+	maxPause := battle.MaxPause(h.rules.Current(), run.DungeonRank)
+	paused := store.PausedTotal(run.Pauses, now)
+	v := runView{
+		FocusRun:         run,
+		ElapsedSeconds:   int64(battle.Elapsed(run.StartAt, now, paused, maxPause) / time.Second),
+		PauseLeftSeconds: int64(max(maxPause-paused, 0) / time.Second),
+	}
+	for _, p := range run.Pauses {
+		if p.EndedAt == nil {
+			v.Paused = true
+		}
+	}
+	return v
+}
+
+// ownRun carrega o gate do {id}; responde 404 se ele não for do usuário.
+func (h *GateHandler) ownRun(w http.ResponseWriter, r *http.Request) (store.FocusRun, bool) {
+	uid, ok := middleware.UserIDFromContext(r)
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return store.FocusRun{}, false
+	}
+	runID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "invalid id", http.StatusBadRequest)
+		return store.FocusRun{}, false
+	}
+	run, err := store.GetFocusRunByID(r.Context(), h.db, runID)
+	if err != nil || run.UserID.String() != uid {
+		http.Error(w, "Not Found", http.StatusNotFound)
+		return store.FocusRun{}, false
+	}
+	return run, true
+}
+
+func (h *GateHandler) Get(w http.ResponseWriter, r *http.Request) {
+	run, ok := h.ownRun(w, r)
+	if !ok {
+		return
+	}
+	json.NewEncoder(w).Encode(h.view(run))
+}
+
+// Pause pausa o gate. O tempo pausado não conta, até o limite de pausa do rank; passou disso o
+// relógio volta a correr mesmo pausado.
+func (h *GateHandler) Pause(w http.ResponseWriter, r *http.Request) {
+	run, ok := h.ownRun(w, r)
+	if !ok {
+		return
+	}
+	_, err := store.PauseFocusRun(r.Context(), h.db, run.ID, battle.MaxPause(h.rules.Current(), run.DungeonRank))
+	h.respondPause(w, r, run.ID, err)
+}
+
+func (h *GateHandler) Resume(w http.ResponseWriter, r *http.Request) {
+	run, ok := h.ownRun(w, r)
+	if !ok {
+		return
+	}
+	_, err := store.ResumeFocusRun(r.Context(), h.db, run.ID)
+	h.respondPause(w, r, run.ID, err)
+}
+
+func (h *GateHandler) respondPause(w http.ResponseWriter, r *http.Request, runID uuid.UUID, err error) {
+	switch {
+	case err == nil:
+	case errors.Is(err, store.ErrRunClosed), errors.Is(err, store.ErrAlreadyPaused),
+		errors.Is(err, store.ErrNotPaused), errors.Is(err, store.ErrPauseLimit):
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	default:
+		log.Println("pause gate: ", err)
+		http.Error(w, "failed to update run", http.StatusInternalServerError)
+		return
+	}
+	run, err := store.GetFocusRunByID(r.Context(), h.db, runID)
+	if err != nil {
+		http.Error(w, "failed to load run", http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(h.view(run))
 }
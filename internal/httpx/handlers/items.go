@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gabrieldemesio/solo-leveling-go-mvp-v2/internal/httpx/middleware"
+	"github.com/gabrieldemesio/solo-leveling-go-mvp-v2/internal/store"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type ItemsHandler struct {
+	db *pgxpool.Pool
+}
+
+func NewItemsHandler(db *pgxpool.Pool) *ItemsHandler {
+	return &ItemsHandler{db: db}
+}
+
+// Catalog lista todos os itens que existem no jogo.
+func (h *ItemsHandler) Catalog(w http.ResponseWriter, r *http.Request) {
+	items, err := store.ListItems(r.Context(), h.db)
+	if err != nil {
+		http.Error(w, "failed to list items", http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(items)
+}
+
+// Inventory devolve os itens do usuário e os efeitos já ativados esperando um gate.
+func (h *ItemsHandler) Inventory(w http.ResponseWriter, r *http.Request) {
+	uid, ok := middleware.UserIDFromContext(r)
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	id := uuid.MustParse(uid)
+	items, err := store.ListInventory(r.Context(), h.db, id)
+	if err != nil {
+		http.Error(w, "failed to list inventory", http.StatusInternalServerError)
+		return
+	}
+	effects, err := store.ListUserEffects(r.Context(), h.db, id, true)
+	if err != nil {
+		http.Error(w, "failed to list inventory", http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]any{"items": items, "effects": effects})
+}
+
+// Use ativa um item; o efeito fica esperando o próximo gate que puder consumi-lo.
+func (h *ItemsHandler) Use(w http.ResponseWriter, r *http.Request) {
+	uid, ok := middleware.UserIDFromContext(r)
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	e, err := store.UseItem(r.Context(), h.db, uuid.MustParse(uid), chi.URLParam(r, "id"))
+	switch {
+	case err == nil:
+	case errors.Is(err, store.ErrNotInInventory):
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	case errors.Is(err, store.ErrEffectActive):
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	default:
+		log.Println("use item: ", err)
+		http.Error(w, "failed to use item", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(e)
+}
+
+func (h *ItemsHandler) Discard(w http.ResponseWriter, r *http.Request) {
+	uid, ok := middleware.UserIDFromContext(r)
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	var in struct {
+		Quantity int `json:"quantity"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&in); err != nil || in.Quantity < 0 {
+			http.Error(w, "invalid body", http.StatusBadRequest)
+			return
+		}
+	}
+	if in.Quantity == 0 {
+		in.Quantity = 1
+	}
+	err := store.DiscardItem(r.Context(), h.db, uuid.MustParse(uid), chi.URLParam(r, "id"), in.Quantity)
+	switch {
+	case err == nil:
+		w.WriteHeader(http.StatusNoContent)
+	case errors.Is(err, store.ErrNotInInventory):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	default:
+		log.Println("discard item: ", err)
+		http.Error(w, "failed to discard item", http.StatusInternalServerError)
+	}
+}
@@ -2,8 +2,11 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 
+	"github.com/gabrieldemesio/solo-leveling-go-mvp-v2/internal/core/rank"
+	"github.com/gabrieldemesio/solo-leveling-go-mvp-v2/internal/core/stats"
 	"github.com/gabrieldemesio/solo-leveling-go-mvp-v2/internal/httpx/middleware"
 	"github.com/gabrieldemesio/solo-leveling-go-mvp-v2/internal/store"
 	"github.com/google/uuid"
@@ -31,3 +34,116 @@ func (h *MeHandler) Me(w http.ResponseWriter, r *http.Request) {
 	}
 	json.NewEncoder(w).Encode(u)
 }
+
+// AllocateStats gasta pontos livres: o body é {"focus": 2, "energy": 1, ...}.
+func (h *MeHandler) AllocateStats(w http.ResponseWriter, r *http.Request) {
+	uid, ok := middleware.UserIDFromContext(r)
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	var alloc map[string]int
+	if err := json.NewDecoder(r.Body).Decode(&alloc); err != nil {
+		http.Error(w, "invalid body", http.StatusBadRequest)
+		return
+	}
+	total, err := stats.ValidateAllocation(alloc)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	u, err := store.AllocateStatPoints(r.Context(), h.db, uuid.MustParse(uid), alloc, total)
+	switch {
+	case err == nil:
+	case errors.Is(err, store.ErrNotEnoughStatPoints):
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	default:
+		http.Error(w, "failed to allocate stats", http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(u)
+}
+
+// RespecStats zera a distribuição (todos os stats voltam pra base) em troca de gold.
+func (h *MeHandler) RespecStats(w http.ResponseWriter, r *http.Request) {
+	uid, ok := middleware.UserIDFromContext(r)
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	u, err := store.GetUserByID(r.Context(), h.db, uuid.MustParse(uid))
+	if err != nil {
+		http.Error(w, "Not Found", http.StatusNotFound)
+		return
+	}
+	u, err = store.RespecStats(r.Context(), h.db, u.ID, stats.BaseStats(), stats.Spent, stats.RespecCost(u.Level))
+	switch {
+	case err == nil:
+	case errors.Is(err, store.ErrInsufficientGold), errors.Is(err, store.ErrNothingToRespec):
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	default:
+		http.Error(w, "failed to reset stats", http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(u)
+}
+
+// Rank mostra o rank do hunter e o quanto falta pra reavaliação pro próximo.
+func (h *MeHandler) Rank(w http.ResponseWriter, r *http.Request) {
+	uid, ok := middleware.UserIDFromContext(r)
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	u, err := store.GetUserByID(r.Context(), h.db, uuid.MustParse(uid))
+	if err != nil {
+		http.Error(w, "Not Found", http.StatusNotFound)
+		return
+	}
+	clears, err := store.CountClears(r.Context(), h.db, u.ID, rank.MaxDungeon(u.HunterRank))
+	if err != nil {
+		http.Error(w, "failed to load rank", http.StatusInternalServerError)
+		return
+	}
+	out := struct {
+		HunterRank     string            `json:"hunterRank"`
+		MaxDungeonRank string            `json:"maxDungeonRank"`
+		Level          int               `json:"level"`
+		Clears         int               `json:"clears"`
+		Next           string            `json:"next,omitempty"`
+		Requirement    *rank.Requirement `json:"requirement,omitempty"`
+		Eligible       bool              `json:"eligible"`
+	}{
+		HunterRank:     u.HunterRank,
+		MaxDungeonRank: rank.MaxDungeon(u.HunterRank),
+		Level:          u.Level,
+		Clears:         clears,
+		Eligible:       rank.Eligible(u.HunterRank, u.Level, clears),
+	}
+	if next, ok := rank.Next(u.HunterRank); ok {
+		req := rank.Requirements[next]
+		out.Next, out.Requirement = next, &req
+	}
+	json.NewEncoder(w).Encode(out)
+}
+
+// Ledger lista as movimentações de XP e gold do usuário, da mais nova pra mais antiga.
+func (h *MeHandler) Ledger(w http.ResponseWriter, r *http.Request) {
+	uid, ok := middleware.UserIDFromContext(r)
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	limit, offset, ok := pageParams(w, r)
+	if !ok {
+		return
+	}
+	entries, err := store.ListLedgerEntries(r.Context(), h.db, uuid.MustParse(uid), limit, offset)
+	if err != nil {
+		http.Error(w, "failed to list ledger", http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(entries)
+}
@@ -0,0 +1,256 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gabrieldemesio/solo-leveling-go-mvp-v2/internal/auth/signing"
+	"github.com/gabrieldemesio/solo-leveling-go-mvp-v2/internal/auth/throttle"
+	"github.com/gabrieldemesio/solo-leveling-go-mvp-v2/internal/auth/totp"
+	"github.com/gabrieldemesio/solo-leveling-go-mvp-v2/internal/httpx/middleware"
+	"github.com/gabrieldemesio/solo-leveling-go-mvp-v2/internal/store"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+const (
+	mfaChallengeTTL   = 5 * time.Minute
+	totpIssuer        = "Solo Leveling"
+	recoveryCodeCount = 10
+)
+
+// signMFAChallenge leva a geração de tokens atual: se a conta for banida ou tiver as sessões
+// derrubadas entre a senha e o código, o desafio deixa de valer.
+func (h *AuthHandler) signMFAChallenge(ctx context.Context, userID uuid.UUID) (string, error) {
+	gen, _, err := store.GetTokenClaims(ctx, h.db, userID)
+	if err != nil {
+		return "", err
+	}
+	now := time.Now()
+	return h.keys.Sign(jwt.MapClaims{
+		"iss": "solo-leveling",
+		"aud": signing.AudienceMFA,
+		"typ": "mfa",
+		"sub": userID.String(),
+		"iat": now.Unix(),
+		"exp": now.Add(mfaChallengeTTL).Unix(),
+		"gen": gen,
+	})
+}
+
+// EnrollTOTP gera um segredo pendente; ele só passa a valer depois de ConfirmTOTP com um código válido.
+func (h *AuthHandler) EnrollTOTP(w http.ResponseWriter, r *http.Request) {
+	uid, ok := middleware.UserIDFromContext(r)
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	u, err := store.GetUserByID(r.Context(), h.db, uuid.MustParse(uid))
+	if err != nil {
+		http.Error(w, "Not Found", http.StatusNotFound)
+		return
+	}
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		http.Error(w, "failed to enroll", http.StatusInternalServerError)
+		return
+	}
+	if err := store.StartTOTPEnrollment(r.Context(), h.db, u.ID, secret); err != nil {
+		if errors.Is(err, store.ErrMFAAlreadyEnabled) {
+			http.Error(w, "mfa already enabled", http.StatusConflict)
+			return
+		}
+		http.Error(w, "failed to enroll", http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]any{
+		"secret":     secret,
+		"otpauthUri": totp.URI(totpIssuer, u.Email, secret),
+	})
+}
+
+// ConfirmTOTP ativa o 2FA e devolve os códigos de recuperação (a única vez que eles aparecem).
+func (h *AuthHandler) ConfirmTOTP(w http.ResponseWriter, r *http.Request) {
+	uid, ok := middleware.UserIDFromContext(r)
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	var in struct {
+		Code string `json:"code"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil || in.Code == "" {
+		http.Error(w, "invalid body", http.StatusBadRequest)
+		return
+	}
+	userID := uuid.MustParse(uid)
+	ip := clientIP(r)
+	if h.rejectIfThrottled(w, r, mfaKey(userID), ipKey(ip)) {
+		return
+	}
+	m, err := store.GetUserMFA(r.Context(), h.db, userID)
+	if err != nil {
+		http.Error(w, "mfa enrollment not started", http.StatusNotFound)
+		return
+	}
+	if m.EnabledAt != nil {
+		http.Error(w, "mfa already enabled", http.StatusConflict)
+		return
+	}
+	step, ok := totp.Validate(m.TOTPSecret, in.Code, time.Now(), m.LastUsedStep)
+	if !ok {
+		h.recordFailure(r.Context(), mfaKey(userID), throttle.Account, ip, &userID)
+		http.Error(w, "invalid code", http.StatusUnauthorized)
+		return
+	}
+	_ = store.ClearLoginFailures(r.Context(), h.db, mfaKey(userID))
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		http.Error(w, "failed to enable mfa", http.StatusInternalServerError)
+		return
+	}
+	if err := store.EnableTOTP(r.Context(), h.db, userID, step, hashes); err != nil {
+		if errors.Is(err, store.ErrMFAAlreadyEnabled) {
+			http.Error(w, "mfa already enabled", http.StatusConflict)
+			return
+		}
+		http.Error(w, "failed to enable mfa", http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]any{"recoveryCodes": codes})
+}
+
+func (h *AuthHandler) DisableTOTP(w http.ResponseWriter, r *http.Request) {
+	uid, ok := middleware.UserIDFromContext(r)
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	var in struct {
+		Code         string `json:"code"`
+		RecoveryCode string `json:"recoveryCode"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		http.Error(w, "invalid body", http.StatusBadRequest)
+		return
+	}
+	userID := uuid.MustParse(uid)
+	ip := clientIP(r)
+	if h.rejectIfThrottled(w, r, mfaKey(userID), ipKey(ip)) {
+		return
+	}
+	ok, err := h.checkSecondFactor(r.Context(), userID, in.Code, in.RecoveryCode)
+	if err != nil {
+		http.Error(w, "failed to disable mfa", http.StatusInternalServerError)
+		return
+	}
+	if !ok {
+		h.recordFailure(r.Context(), mfaKey(userID), throttle.Account, ip, &userID)
+		http.Error(w, "invalid code", http.StatusUnauthorized)
+		return
+	}
+	_ = store.ClearLoginFailures(r.Context(), h.db, mfaKey(userID))
+	if err := store.DisableTOTP(r.Context(), h.db, userID); err != nil {
+		http.Error(w, "failed to disable mfa", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// VerifyMFA é o segundo passo do login: troca o mfaToken + código (TOTP ou recuperação) pelos tokens.
+func (h *AuthHandler) VerifyMFA(w http.ResponseWriter, r *http.Request) {
+	var in struct {
+		MFAToken     string `json:"mfaToken"`
+		Code         string `json:"code"`
+		RecoveryCode string `json:"recoveryCode"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil || in.MFAToken == "" {
+		http.Error(w, "invalid body", http.StatusBadRequest)
+		return
+	}
+	claims := jwt.MapClaims{}
+	if err := h.keys.Parse(in.MFAToken, claims, jwt.WithIssuer("solo-leveling"), jwt.WithAudience(signing.AudienceMFA)); err != nil || claims["typ"] != "mfa" {
+		http.Error(w, "invalid or expired mfa token", http.StatusUnauthorized)
+		return
+	}
+	sub, _ := claims["sub"].(string)
+	userID, err := uuid.Parse(sub)
+	if err != nil {
+		http.Error(w, "invalid or expired mfa token", http.StatusUnauthorized)
+		return
+	}
+	ip := clientIP(r)
+	key := mfaKey(userID)
+	if h.rejectIfThrottled(w, r, key, ipKey(ip)) {
+		return
+	}
+	ok, err := h.checkSecondFactor(r.Context(), userID, in.Code, in.RecoveryCode)
+	if err != nil {
+		http.Error(w, "failed to verify code", http.StatusInternalServerError)
+		return
+	}
+	if !ok {
+		h.recordFailure(r.Context(), key, throttle.Account, ip, &userID)
+		http.Error(w, "invalid code", http.StatusUnauthorized)
+		return
+	}
+	_ = store.ClearLoginFailures(r.Context(), h.db, key)
+	gen, _, err := store.GetTokenClaims(r.Context(), h.db, userID)
+	if err != nil {
+		http.Error(w, "failed to verify code", http.StatusInternalServerError)
+		return
+	}
+	if claimGen, _ := claims["gen"].(float64); int(claimGen) != gen {
+		http.Error(w, "invalid or expired mfa token", http.StatusUnauthorized)
+		return
+	}
+	tokens, err := h.issueTokens(r, userID)
+	if err != nil {
+		http.Error(w, "failed to issue token", http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(tokens)
+}
+
+// checkSecondFactor aceita um código TOTP (sem replay) ou um código de recuperação ainda não usado.
+func (h *AuthHandler) checkSecondFactor(ctx context.Context, userID uuid.UUID, code, recoveryCode string) (bool, error) {
+	if recoveryCode != "" {
+		return store.UseRecoveryCode(ctx, h.db, userID, hashToken(normalizeRecoveryCode(recoveryCode)))
+	}
+	m, err := store.GetUserMFA(ctx, h.db, userID)
+	if err != nil || m.EnabledAt == nil {
+		return false, nil
+	}
+	step, ok := totp.Validate(m.TOTPSecret, code, time.Now(), m.LastUsedStep)
+	if !ok {
+		return false, nil
+	}
+	return store.UseTOTPStep(ctx, h.db, userID, step)
+}
+
+// newRecoveryCodes gera códigos no formato xxxxx-xxxxx; só o hash vai pro banco.
+func newRecoveryCodes() (codes, hashes []string, err error) {
+	enc := base32.StdEncoding.WithPadding(base32.NoPadding)
+	for i := 0; i < recoveryCodeCount; i++ {
+		b := make([]byte, 7)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, err
+		}
+		s := strings.ToLower(enc.EncodeToString(b))[:10]
+		code := s[:5] + "-" + s[5:]
+		codes = append(codes, code)
+		hashes = append(hashes, hashToken(normalizeRecoveryCode(code)))
+	}
+	return codes, hashes, nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
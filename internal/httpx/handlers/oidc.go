@@ -0,0 +1,198 @@
+package handlers
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gabrieldemesio/solo-leveling-go-mvp-v2/internal/auth/oidc"
+	"github.com/gabrieldemesio/solo-leveling-go-mvp-v2/internal/store"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	oidcStateTTL    = 10 * time.Minute
+	oidcStateCookie = "oidc_state"
+	oidcCookiePath  = "/v1/auth/oidc"
+)
+
+// OIDCLogin gera state, nonce e code_verifier (PKCE), guarda no banco e manda o usuário pro IdP.
+// O state também vai num cookie HttpOnly: o callback só aceita o state vindo do mesmo navegador
+// que começou o login, senão dava pra logar a vítima na conta de outro (login CSRF).
+func (h *AuthHandler) OIDCLogin(w http.ResponseWriter, r *http.Request) {
+	var vals [3]string
+	for i := range vals {
+		v, err := oidc.RandomString()
+		if err != nil {
+			http.Error(w, "failed to start login", http.StatusInternalServerError)
+			return
+		}
+		vals[i] = v
+	}
+	state, nonce, verifier := vals[0], vals[1], vals[2]
+	if err := store.CreateOIDCState(r.Context(), h.db, state, nonce, verifier, time.Now().Add(oidcStateTTL)); err != nil {
+		http.Error(w, "failed to start login", http.StatusInternalServerError)
+		return
+	}
+	u, err := h.oidc.AuthCodeURL(r.Context(), state, nonce, verifier)
+	if err != nil {
+		log.Println("oidc: ", err)
+		http.Error(w, "identity provider unavailable", http.StatusBadGateway)
+		return
+	}
+	h.setOIDCStateCookie(w, state, int(oidcStateTTL.Seconds()))
+	http.Redirect(w, r, u, http.StatusFound)
+}
+
+// setOIDCStateCookie grava (ou apaga, com maxAge < 0) o cookie do state. SameSite=Lax porque o
+// callback chega por um redirect de outro site.
+func (h *AuthHandler) setOIDCStateCookie(w http.ResponseWriter, state string, maxAge int) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcStateCookie,
+		Value:    state,
+		Path:     oidcCookiePath,
+		MaxAge:   maxAge,
+		HttpOnly: true,
+		Secure:   strings.HasPrefix(h.publicURL, "https://"),
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+func (h *AuthHandler) OIDCCallback(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	if e := q.Get("error"); e != "" {
+		http.Error(w, "login failed: "+e, http.StatusUnauthorized)
+		return
+	}
+	code, state := q.Get("code"), q.Get("state")
+	if code == "" || state == "" {
+		http.Error(w, "invalid callback", http.StatusBadRequest)
+		return
+	}
+	c, err := r.Cookie(oidcStateCookie)
+	if err != nil || subtle.ConstantTimeCompare([]byte(c.Value), []byte(state)) != 1 {
+		http.Error(w, "invalid or expired state", http.StatusBadRequest)
+		return
+	}
+	h.setOIDCStateCookie(w, "", -1)
+	nonce, verifier, err := store.ConsumeOIDCState(r.Context(), h.db, state)
+	if err != nil {
+		http.Error(w, "invalid or expired state", http.StatusBadRequest)
+		return
+	}
+	ident, err := h.oidc.Exchange(r.Context(), code, verifier, nonce)
+	if err != nil {
+		log.Println("oidc exchange: ", err)
+		http.Error(w, "login failed", http.StatusUnauthorized)
+		return
+	}
+	userID, err := h.userForIdentity(r.Context(), ident)
+	if err != nil {
+		log.Println("oidc user: ", err)
+		http.Error(w, "login failed", http.StatusConflict)
+		return
+	}
+	u, err := store.GetUserByID(r.Context(), h.db, userID)
+	if err != nil {
+		http.Error(w, "login failed", http.StatusInternalServerError)
+		return
+	}
+	if u.BannedAt != nil {
+		http.Error(w, "account banned", http.StatusForbidden)
+		return
+	}
+	if u.DeleteAfter != nil {
+		http.Error(w, "account scheduled for deletion", http.StatusForbidden)
+		return
+	}
+	mfa, err := store.MFAEnabled(r.Context(), h.db, userID)
+	if err != nil {
+		http.Error(w, "login failed", http.StatusInternalServerError)
+		return
+	}
+	if mfa {
+		// o IdP vale como senha, não como segundo fator: os tokens só saem em /v1/auth/mfa
+		challenge, err := h.signMFAChallenge(r.Context(), userID)
+		if err != nil {
+			http.Error(w, "failed to issue token", http.StatusInternalServerError)
+			return
+		}
+		if h.oidcPostLoginRedirect == "" {
+			json.NewEncoder(w).Encode(map[string]any{"mfaRequired": true, "mfaToken": challenge})
+			return
+		}
+		frag := url.Values{"mfaRequired": {"true"}, "mfaToken": {challenge}}
+		http.Redirect(w, r, h.oidcPostLoginRedirect+"#"+frag.Encode(), http.StatusFound)
+		return
+	}
+	tokens, err := h.issueTokens(r, userID)
+	if err != nil {
+		http.Error(w, "failed to issue token", http.StatusInternalServerError)
+		return
+	}
+	if h.oidcPostLoginRedirect == "" {
+		json.NewEncoder(w).Encode(tokens)
+		return
+	}
+	// tokens vão no fragment pra não aparecerem em logs de servidor nem no Referer
+	frag := url.Values{
+		"accessToken":  {tokens.AccessToken},
+		"refreshToken": {tokens.RefreshToken},
+		"expiresIn":    {strconv.Itoa(tokens.ExpiresIn)},
+	}
+	http.Redirect(w, r, h.oidcPostLoginRedirect+"#"+frag.Encode(), http.StatusFound)
+}
+
+// userForIdentity resolve a identidade externa: já ligada → usuário dela; e-mail verificado pelo IdP
+// que bate com uma conta existente → liga nela; senão cria um usuário novo (sem senha local).
+func (h *AuthHandler) userForIdentity(ctx context.Context, ident *oidc.Identity) (uuid.UUID, error) {
+	id, err := store.GetUserIDByIdentity(ctx, h.db, ident.Issuer, ident.Subject)
+	if err == nil {
+		return id, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return uuid.Nil, err
+	}
+	if ident.Email == "" {
+		return uuid.Nil, errors.New("identity provider did not return an email")
+	}
+
+	now := time.Now()
+	link := store.UserIdentity{
+		ID:          uuid.New(),
+		Provider:    ident.Issuer,
+		Subject:     ident.Subject,
+		Email:       &ident.Email,
+		CreatedAt:   now,
+		LastLoginAt: &now,
+	}
+	if existing, err := store.GetUserByEmail(ctx, h.db, ident.Email); err == nil {
+		// sem email_verified do IdP, ligar por e-mail deixaria qualquer um tomar a conta
+		if !ident.EmailVerified {
+			return uuid.Nil, errors.New("email already registered and not verified by the identity provider")
+		}
+		link.UserID = existing.ID
+		if err := store.CreateUserIdentity(ctx, h.db, &link); err != nil {
+			return uuid.Nil, err
+		}
+		return existing.ID, nil
+	}
+
+	u := newUser(ident.Email, "")
+	if ident.EmailVerified {
+		u.EmailVerifiedAt = &now
+	}
+	link.UserID = u.ID
+	if err := store.CreateUserWithIdentity(ctx, h.db, &u, &link); err != nil {
+		return uuid.Nil, err
+	}
+	return u.ID, nil
+}
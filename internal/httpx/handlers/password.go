@@ -0,0 +1,204 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gabrieldemesio/solo-leveling-go-mvp-v2/internal/auth/throttle"
+	"github.com/gabrieldemesio/solo-leveling-go-mvp-v2/internal/httpx/middleware"
+	"github.com/gabrieldemesio/solo-leveling-go-mvp-v2/internal/mail"
+	"github.com/gabrieldemesio/solo-leveling-go-mvp-v2/internal/store"
+	"github.com/google/uuid"
+)
+
+const (
+	passwordResetTTL  = time.Hour
+	passwordResetSend = 30 * time.Second
+)
+
+// Forgot sempre responde 202, exista a conta ou não, pra não vazar quais e-mails estão cadastrados.
+// A busca e o envio rodam depois da resposta, senão o tempo dela denunciaria a conta. Todo pedido
+// conta no throttle (pelo e-mail e pelo IP), senão dava pra inundar a caixa de alguém com resets.
+func (h *AuthHandler) Forgot(w http.ResponseWriter, r *http.Request) {
+	var in struct {
+		Email string `json:"email"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil || in.Email == "" {
+		http.Error(w, "invalid body", http.StatusBadRequest)
+		return
+	}
+	ip := clientIP(r)
+	if h.rejectIfThrottled(w, r, resetKey(in.Email), resetIPKey(ip)) {
+		return
+	}
+	h.recordFailure(r.Context(), resetKey(in.Email), throttle.PasswordReset, ip, nil)
+	h.recordFailure(r.Context(), resetIPKey(ip), throttle.PasswordResetIP, ip, nil)
+	w.WriteHeader(http.StatusAccepted)
+
+	go func(email string) {
+		ctx, cancel := context.WithTimeout(context.Background(), passwordResetSend)
+		defer cancel()
+		u, err := store.GetUserByEmail(ctx, h.db, email)
+		if err != nil {
+			return
+		}
+		if err := h.sendPasswordResetEmail(ctx, u,
+			"Recebemos um pedido para redefinir sua senha.", "Se não foi você, ignore este e-mail."); err != nil {
+			log.Println("send password reset email: ", err)
+		}
+	}(in.Email)
+}
+
+// sendPasswordResetEmail cria um token de reset e manda o link entre intro e outro.
+func (h *AuthHandler) sendPasswordResetEmail(ctx context.Context, u *store.User, intro, outro string) error {
+	raw, hash, err := newOpaqueToken()
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	t := store.PasswordResetToken{
+		ID:        uuid.New(),
+		UserID:    u.ID,
+		TokenHash: hash,
+		ExpiresAt: now.Add(passwordResetTTL),
+		CreatedAt: now,
+	}
+	if err := store.CreatePasswordResetToken(ctx, h.db, &t); err != nil {
+		return err
+	}
+	sep := "?"
+	if strings.Contains(h.resetURL, "?") {
+		sep = "&"
+	}
+	link := h.resetURL + sep + "token=" + url.QueryEscape(raw)
+	return h.mailer.Send(ctx, mail.Message{
+		To:      u.Email,
+		Subject: "Redefinição de senha",
+		Body: intro + " O link abaixo vale por 1 hora e só pode ser usado uma vez:\n\n" +
+			link + "\n\n" + outro + "\n",
+	})
+}
+
+// resetPage é o formulário mínimo servido em GET /v1/auth/reset quando não há PASSWORD_RESET_URL.
+const resetPage = `<!doctype html>
+<html lang="pt-BR">
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>Redefinir senha</title>
+<form id="f">
+  <h1>Redefinir senha</h1>
+  <input id="p" type="password" placeholder="Nova senha" autocomplete="new-password" required>
+  <button>Salvar</button>
+  <p id="m"></p>
+</form>
+<script>
+const token = new URLSearchParams(location.search).get("token") || "";
+document.getElementById("f").onsubmit = async (e) => {
+  e.preventDefault();
+  const res = await fetch(location.pathname, {
+    method: "POST",
+    headers: {"Content-Type": "application/json"},
+    body: JSON.stringify({token, password: document.getElementById("p").value}),
+  });
+  document.getElementById("m").textContent = res.ok ? "Senha alterada. Já pode entrar." : await res.text();
+};
+</script>
+</html>
+`
+
+func (h *AuthHandler) ResetPage(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Referrer-Policy", "no-referrer")
+	io.WriteString(w, resetPage)
+}
+
+func (h *AuthHandler) Reset(w http.ResponseWriter, r *http.Request) {
+	var in struct {
+		Token    string `json:"token"`
+		Password string `json:"password"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil || in.Token == "" || in.Password == "" {
+		http.Error(w, "invalid body", http.StatusBadRequest)
+		return
+	}
+	if err := h.policy.Check(in.Password); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	hash, err := h.hasher.Hash(in.Password)
+	if err != nil {
+		http.Error(w, "failed to reset password", http.StatusInternalServerError)
+		return
+	}
+	if _, err := store.ResetPassword(r.Context(), h.db, hashToken(in.Token), hash); err != nil {
+		if errors.Is(err, store.ErrResetTokenInvalid) {
+			http.Error(w, "invalid or expired token", http.StatusBadRequest)
+			return
+		}
+		http.Error(w, "failed to reset password", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ChangePassword exige a senha atual; as outras sessões caem e o cliente atual recebe tokens novos.
+// Errar a senha atual conta como falha de login da conta, com o mesmo backoff/lockout.
+func (h *AuthHandler) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	uid, ok := middleware.UserIDFromContext(r)
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	var in struct {
+		CurrentPassword string `json:"currentPassword"`
+		NewPassword     string `json:"newPassword"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil || in.CurrentPassword == "" || in.NewPassword == "" {
+		http.Error(w, "invalid body", http.StatusBadRequest)
+		return
+	}
+	u, err := store.GetUserByID(r.Context(), h.db, uuid.MustParse(uid))
+	if err != nil {
+		http.Error(w, "Not Found", http.StatusNotFound)
+		return
+	}
+	ip := clientIP(r)
+	if h.rejectIfThrottled(w, r, accountKey(u.Email), ipKey(ip)) {
+		return
+	}
+	if ok, err := h.hasher.Verify(in.CurrentPassword, u.PassHash); err != nil || !ok {
+		h.recordLoginFailure(r.Context(), u.Email, ip, &u.ID)
+		http.Error(w, "invalid credentials", http.StatusUnauthorized)
+		return
+	}
+	if err := store.ClearLoginFailures(r.Context(), h.db, accountKey(u.Email)); err != nil {
+		log.Println("clear login failures: ", err)
+	}
+	if err := h.policy.Check(in.NewPassword); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	hash, err := h.hasher.Hash(in.NewPassword)
+	if err != nil {
+		http.Error(w, "failed to change password", http.StatusInternalServerError)
+		return
+	}
+	if err := store.ChangePassword(r.Context(), h.db, u.ID, hash); err != nil {
+		http.Error(w, "failed to change password", http.StatusInternalServerError)
+		return
+	}
+	tokens, err := h.issueTokens(r, u.ID)
+	if err != nil {
+		http.Error(w, "failed to issue token", http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(tokens)
+}
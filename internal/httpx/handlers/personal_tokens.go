@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gabrieldemesio/solo-leveling-go-mvp-v2/internal/httpx/middleware"
+	"github.com/gabrieldemesio/solo-leveling-go-mvp-v2/internal/store"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type TokensHandler struct {
+	db *pgxpool.Pool
+}
+
+func NewTokensHandler(db *pgxpool.Pool) *TokensHandler {
+	return &TokensHandler{db: db}
+}
+func (h *TokensHandler) List(w http.ResponseWriter, r *http.Request) {
+	uid, ok := middleware.UserIDFromContext(r)
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	items, err := store.ListPersonalAccessTokens(r.Context(), h.db, uuid.MustParse(uid))
+	if err != nil {
+		http.Error(w, "failed to list tokens", http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(items)
+}
+
+// Create devolve o token em texto puro uma única vez; depois disso só o prefixo fica visível.
+func (h *TokensHandler) Create(w http.ResponseWriter, r *http.Request) {
+	uid, ok := middleware.UserIDFromContext(r)
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	var in struct {
+		Name      string     `json:"name"`
+		Scopes    []string   `json:"scopes"`
+		ExpiresAt *time.Time `json:"expiresAt"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil || strings.TrimSpace(in.Name) == "" || len(in.Scopes) == 0 {
+		http.Error(w, "invalid body", http.StatusBadRequest)
+		return
+	}
+	for _, s := range in.Scopes {
+		if !slices.Contains(middleware.PATScopes, s) {
+			http.Error(w, "invalid scope: "+s, http.StatusBadRequest)
+			return
+		}
+	}
+	if in.ExpiresAt != nil && in.ExpiresAt.Before(time.Now()) {
+		http.Error(w, "expiresAt must be in the future", http.StatusBadRequest)
+		return
+	}
+	secret, _, err := newOpaqueToken()
+	if err != nil {
+		http.Error(w, "failed to create token", http.StatusInternalServerError)
+		return
+	}
+	raw := middleware.PATPrefix + secret
+	scopes := slices.Clone(in.Scopes)
+	slices.Sort(scopes)
+	t := store.PersonalAccessToken{
+		ID:        uuid.New(),
+		UserID:    uuid.MustParse(uid),
+		Name:      strings.TrimSpace(in.Name),
+		TokenHash: hashToken(raw),
+		Prefix:    raw[:len(middleware.PATPrefix)+6],
+		Scopes:    slices.Compact(scopes),
+		ExpiresAt: in.ExpiresAt,
+		CreatedAt: time.Now(),
+	}
+	if err := store.CreatePersonalAccessToken(r.Context(), h.db, &t); err != nil {
+		http.Error(w, "failed to create token", http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(struct {
+		store.PersonalAccessToken
+		Token string `json:"token"`
+	}{t, raw})
+}
+
+func (h *TokensHandler) Revoke(w http.ResponseWriter, r *http.Request) {
+	uid, ok := middleware.UserIDFromContext(r)
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "invalid id", http.StatusBadRequest)
+		return
+	}
+	found, err := store.RevokePersonalAccessToken(r.Context(), h.db, uuid.MustParse(uid), id)
+	if err != nil {
+		http.Error(w, "failed to revoke token", http.StatusInternalServerError)
+		return
+	}
+	if !found {
+		http.Error(w, "Not Found", http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
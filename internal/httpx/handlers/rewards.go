@@ -0,0 +1,186 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gabrieldemesio/solo-leveling-go-mvp-v2/internal/httpx/middleware"
+	"github.com/gabrieldemesio/solo-leveling-go-mvp-v2/internal/store"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// RewardsHandler é a loja de gold: recompensas da vida real que o próprio usuário cadastra.
+type RewardsHandler struct {
+	db *pgxpool.Pool
+}
+
+func NewRewardsHandler(db *pgxpool.Pool) *RewardsHandler {
+	return &RewardsHandler{db: db}
+}
+
+func (h *RewardsHandler) List(w http.ResponseWriter, r *http.Request) {
+	uid, ok := middleware.UserIDFromContext(r)
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	items, err := store.ListShopRewards(r.Context(), h.db, uuid.MustParse(uid))
+	if err != nil {
+		http.Error(w, "failed to list rewards", http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(items)
+}
+
+func (h *RewardsHandler) Create(w http.ResponseWriter, r *http.Request) {
+	uid, ok := middleware.UserIDFromContext(r)
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	var in struct {
+		Title       string  `json:"title"`
+		Description *string `json:"description"`
+		Price       int64   `json:"price"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil || strings.TrimSpace(in.Title) == "" {
+		http.Error(w, "invalid body", http.StatusBadRequest)
+		return
+	}
+	if in.Price <= 0 {
+		http.Error(w, "price must be > 0", http.StatusBadRequest)
+		return
+	}
+	s := store.ShopReward{
+		ID:          uuid.New(),
+		UserID:      uuid.MustParse(uid),
+		Title:       in.Title,
+		Description: in.Description,
+		Price:       in.Price,
+		CreatedAt:   time.Now(),
+	}
+	if err := store.CreateShopReward(r.Context(), h.db, &s); err != nil {
+		http.Error(w, "failed to create reward", http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(s)
+}
+
+func (h *RewardsHandler) Patch(w http.ResponseWriter, r *http.Request) {
+	s, ok := h.ownReward(w, r)
+	if !ok {
+		return
+	}
+	var in struct {
+		Title       *string `json:"title"`
+		Description *string `json:"description"`
+		Price       *int64  `json:"price"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		http.Error(w, "invalid body", http.StatusBadRequest)
+		return
+	}
+	if in.Price != nil && *in.Price <= 0 {
+		http.Error(w, "price must be > 0", http.StatusBadRequest)
+		return
+	}
+	if in.Title != nil && strings.TrimSpace(*in.Title) != "" {
+		s.Title = *in.Title
+	}
+	if in.Description != nil {
+		s.Description = in.Description
+	}
+	if in.Price != nil {
+		s.Price = *in.Price
+	}
+	if err := store.UpdateShopReward(r.Context(), h.db, s); err != nil {
+		http.Error(w, "failed to update reward", http.StatusBadRequest)
+		return
+	}
+	json.NewEncoder(w).Encode(s)
+}
+
+// Delete apaga a recompensa; os resgates dela continuam no histórico.
+func (h *RewardsHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	s, ok := h.ownReward(w, r)
+	if !ok {
+		return
+	}
+	if err := store.DeleteShopReward(r.Context(), h.db, s.ID); err != nil {
+		http.Error(w, "failed to delete reward", http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Redeem desconta o preço do gold e registra o resgate; 409 se não tiver gold suficiente.
+func (h *RewardsHandler) Redeem(w http.ResponseWriter, r *http.Request) {
+	uid, ok := middleware.UserIDFromContext(r)
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "invalid id", http.StatusBadRequest)
+		return
+	}
+	rd, gold, err := store.RedeemShopReward(r.Context(), h.db, uuid.MustParse(uid), id)
+	switch {
+	case err == nil:
+	case errors.Is(err, pgx.ErrNoRows):
+		http.Error(w, "Not Found", http.StatusNotFound)
+		return
+	case errors.Is(err, store.ErrInsufficientGold):
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	default:
+		log.Println("redeem reward: ", err)
+		http.Error(w, "failed to redeem reward", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]any{"redemption": rd, "gold": gold})
+}
+
+func (h *RewardsHandler) Redemptions(w http.ResponseWriter, r *http.Request) {
+	uid, ok := middleware.UserIDFromContext(r)
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	items, err := store.ListRewardRedemptions(r.Context(), h.db, uuid.MustParse(uid))
+	if err != nil {
+		http.Error(w, "failed to list redemptions", http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(items)
+}
+
+// ownReward carrega a recompensa do {id}; responde 404 se ela não for do usuário.
+func (h *RewardsHandler) ownReward(w http.ResponseWriter, r *http.Request) (*store.ShopReward, bool) {
+	uid, ok := middleware.UserIDFromContext(r)
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return nil, false
+	}
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "invalid id", http.StatusBadRequest)
+		return nil, false
+	}
+	s, err := store.GetShopRewardByID(r.Context(), h.db, id)
+	if err != nil || s.UserID.String() != uid {
+		http.Error(w, "Not Found", http.StatusNotFound)
+		return nil, false
+	}
+	return s, true
+}
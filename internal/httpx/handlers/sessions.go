@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gabrieldemesio/solo-leveling-go-mvp-v2/internal/httpx/middleware"
+	"github.com/gabrieldemesio/solo-leveling-go-mvp-v2/internal/store"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type SessionsHandler struct {
+	db *pgxpool.Pool
+}
+
+func NewSessionsHandler(db *pgxpool.Pool) *SessionsHandler {
+	return &SessionsHandler{db: db}
+}
+
+type sessionView struct {
+	store.Session
+	Current bool `json:"current"`
+}
+
+// List devolve as sessões ativas, marcando a do token usado no request.
+func (h *SessionsHandler) List(w http.ResponseWriter, r *http.Request) {
+	uid, ok := middleware.UserIDFromContext(r)
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	items, err := store.ListActiveSessions(r.Context(), h.db, uuid.MustParse(uid))
+	if err != nil {
+		http.Error(w, "failed to list sessions", http.StatusInternalServerError)
+		return
+	}
+	tok, _ := middleware.TokenFromContext(r)
+	out := make([]sessionView, 0, len(items))
+	for _, s := range items {
+		out = append(out, sessionView{Session: s, Current: s.ID == tok.SessionID})
+	}
+	json.NewEncoder(w).Encode(out)
+}
+
+func (h *SessionsHandler) Revoke(w http.ResponseWriter, r *http.Request) {
+	uid, ok := middleware.UserIDFromContext(r)
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "invalid id", http.StatusBadRequest)
+		return
+	}
+	found, err := store.RevokeSession(r.Context(), h.db, uuid.MustParse(uid), id)
+	if err != nil {
+		http.Error(w, "failed to revoke session", http.StatusInternalServerError)
+		return
+	}
+	if !found {
+		http.Error(w, "Not Found", http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
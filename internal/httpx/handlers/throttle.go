@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gabrieldemesio/solo-leveling-go-mvp-v2/internal/auth/throttle"
+	"github.com/gabrieldemesio/solo-leveling-go-mvp-v2/internal/store"
+	"github.com/google/uuid"
+)
+
+func accountKey(email string) string {
+	return "account:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+func ipKey(ip string) string {
+	return "ip:" + ip
+}
+
+// mfaKey é a chave das falhas de código 2FA do usuário: login, confirmação e desativação somam juntos.
+func mfaKey(userID uuid.UUID) string {
+	return "mfa:" + userID.String()
+}
+
+// resetKey e resetIPKey são separados das chaves de login: pedir reset não bloqueia o login.
+func resetKey(email string) string {
+	return "reset:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+func resetIPKey(ip string) string {
+	return "reset-ip:" + ip
+}
+
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// rejectIfThrottled responde 423 (conta em lockout) ou 429 (backoff) com Retry-After se alguma chave estiver bloqueada.
+func (h *AuthHandler) rejectIfThrottled(w http.ResponseWriter, r *http.Request, keys ...string) bool {
+	block, err := store.GetLoginBlock(r.Context(), h.db, keys...)
+	if err != nil {
+		http.Error(w, "failed to login", http.StatusInternalServerError)
+		return true
+	}
+	if block == nil {
+		return false
+	}
+	retry := int(math.Ceil(time.Until(block.LockedUntil).Seconds()))
+	if retry < 1 {
+		retry = 1
+	}
+	w.Header().Set("Retry-After", strconv.Itoa(retry))
+	if block.Lockout {
+		http.Error(w, "account temporarily locked", http.StatusLocked)
+		return true
+	}
+	http.Error(w, "too many attempts", http.StatusTooManyRequests)
+	return true
+}
+
+// recordLoginFailure conta a falha pra conta e pro IP e aplica backoff/lockout. userID é nil se o e-mail não existe.
+func (h *AuthHandler) recordLoginFailure(ctx context.Context, email, ip string, userID *uuid.UUID) {
+	h.recordFailure(ctx, accountKey(email), throttle.Account, ip, userID)
+	h.recordFailure(ctx, ipKey(ip), throttle.IP, ip, nil)
+}
+
+func (h *AuthHandler) recordFailure(ctx context.Context, key string, policy throttle.Policy, ip string, userID *uuid.UUID) {
+	failures, err := store.RecordLoginFailure(ctx, h.db, key, policy.Window)
+	if err != nil {
+		log.Println("record login failure: ", err)
+		return
+	}
+	d, lockout := policy.Decide(failures)
+	if d == 0 {
+		return
+	}
+	if err := store.BlockLogin(ctx, h.db, key, time.Now().Add(d), lockout); err != nil {
+		log.Println("block login: ", err)
+		return
+	}
+	if !lockout {
+		return
+	}
+	e := store.AuditEvent{
+		ID:        uuid.New(),
+		UserID:    userID,
+		Action:    "login.lockout",
+		IP:        ip,
+		Detail:    map[string]any{"key": key, "failures": failures, "lockedForSeconds": int(d.Seconds())},
+		CreatedAt: time.Now(),
+	}
+	if err := store.CreateAuditEvent(ctx, h.db, &e); err != nil {
+		log.Println("audit lockout: ", err)
+	}
+}
@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"net/http"
+	"time"
+
+	"github.com/gabrieldemesio/solo-leveling-go-mvp-v2/internal/auth/signing"
+	"github.com/gabrieldemesio/solo-leveling-go-mvp-v2/internal/store"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+const (
+	accessTokenTTL  = 15 * time.Minute
+	refreshTokenTTL = 30 * 24 * time.Hour
+)
+
+type tokenPair struct {
+	AccessToken  string `json:"accessToken"`
+	RefreshToken string `json:"refreshToken"`
+	ExpiresIn    int    `json:"expiresIn"`
+	// LegacyAccessToken repete o access token na chave antiga (com o typo) do login. Deprecated:
+	// fica só até os clientes migrarem pra accessToken.
+	LegacyAccessToken string `json:"accesToken"`
+}
+
+func newTokenPair(access, refresh string) tokenPair {
+	return tokenPair{AccessToken: access, LegacyAccessToken: access, RefreshToken: refresh, ExpiresIn: int(accessTokenTTL.Seconds())}
+}
+
+// signAccessToken assina um access token da sessão sid com a geração atual de tokens do usuário,
+// que o JWTMiddleware confere a cada request, e o papel dele.
+func (h *AuthHandler) signAccessToken(ctx context.Context, userID, sid uuid.UUID, jti string) (string, error) {
+	gen, role, err := store.GetTokenClaims(ctx, h.db, userID)
+	if err != nil {
+		return "", err
+	}
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"iss":  "solo-leveling",
+		"aud":  signing.AudienceAccess,
+		"typ":  "access",
+		"sub":  userID.String(),
+		"iat":  now.Unix(),
+		"exp":  now.Add(accessTokenTTL).Unix(),
+		"jti":  jti,
+		"sid":  sid.String(),
+		"gen":  gen,
+		"role": role,
+	}
+	return h.keys.Sign(claims)
+}
+
+// issueTokens abre uma sessão nova (um login novo, com a própria família de refresh tokens)
+// e devolve o par de tokens.
+func (h *AuthHandler) issueTokens(r *http.Request, userID uuid.UUID) (tokenPair, error) {
+	ctx := r.Context()
+	raw, hash, err := newOpaqueToken()
+	if err != nil {
+		return tokenPair{}, err
+	}
+	now := time.Now()
+	jti := uuid.NewString()
+	ua, ip := r.UserAgent(), clientIP(r)
+	s := store.Session{
+		ID:         uuid.New(),
+		UserID:     userID,
+		UserAgent:  &ua,
+		IP:         &ip,
+		CurrentJTI: &jti,
+		CreatedAt:  now,
+		LastSeenAt: now,
+	}
+	rt := store.RefreshToken{
+		ID:        uuid.New(),
+		UserID:    userID,
+		FamilyID:  s.ID,
+		TokenHash: hash,
+		ExpiresAt: now.Add(refreshTokenTTL),
+		CreatedAt: now,
+	}
+	if err := store.CreateSession(ctx, h.db, &s, &rt); err != nil {
+		return tokenPair{}, err
+	}
+	access, err := h.signAccessToken(ctx, userID, s.ID, jti)
+	if err != nil {
+		return tokenPair{}, err
+	}
+	return newTokenPair(access, raw), nil
+}
+
+// newOpaqueToken gera um token aleatório para o cliente e o hash que vai pro banco.
+func newOpaqueToken() (raw, hash string, err error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	raw = base64.RawURLEncoding.EncodeToString(b)
+	return raw, hashToken(raw), nil
+}
+
+func hashToken(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
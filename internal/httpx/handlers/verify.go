@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gabrieldemesio/solo-leveling-go-mvp-v2/internal/auth/signing"
+	"github.com/gabrieldemesio/solo-leveling-go-mvp-v2/internal/httpx/middleware"
+	"github.com/gabrieldemesio/solo-leveling-go-mvp-v2/internal/mail"
+	"github.com/gabrieldemesio/solo-leveling-go-mvp-v2/internal/store"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+const emailVerifyTTL = 48 * time.Hour
+
+// sendVerificationEmail manda o link assinado de verificação. O token carrega o e-mail,
+// então trocar o e-mail da conta invalida links antigos.
+func (h *AuthHandler) sendVerificationEmail(ctx context.Context, u *store.User) error {
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"iss":   "solo-leveling",
+		"aud":   signing.AudienceEmailVerify,
+		"typ":   "email_verify",
+		"sub":   u.ID.String(),
+		"email": u.Email,
+		"iat":   now.Unix(),
+		"exp":   now.Add(emailVerifyTTL).Unix(),
+	}
+	token, err := h.keys.Sign(claims)
+	if err != nil {
+		return err
+	}
+	link := fmt.Sprintf("%s/v1/auth/verify?token=%s", h.publicURL, url.QueryEscape(token))
+	return h.mailer.Send(ctx, mail.Message{
+		To:      u.Email,
+		Subject: "Confirme seu e-mail",
+		Body: "Bem-vindo, hunter!\n\nPara confirmar seu e-mail, abra o link abaixo (válido por 48 horas):\n\n" +
+			link + "\n",
+	})
+}
+
+func (h *AuthHandler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	tokenStr := r.URL.Query().Get("token")
+	if tokenStr == "" {
+		http.Error(w, "missing token", http.StatusBadRequest)
+		return
+	}
+	claims := jwt.MapClaims{}
+	err := h.keys.Parse(tokenStr, claims, jwt.WithIssuer("solo-leveling"), jwt.WithAudience(signing.AudienceEmailVerify))
+	if err != nil || claims["typ"] != "email_verify" {
+		http.Error(w, "invalid or expired token", http.StatusBadRequest)
+		return
+	}
+	sub, _ := claims["sub"].(string)
+	email, _ := claims["email"].(string)
+	userID, err := uuid.Parse(sub)
+	if err != nil || email == "" {
+		http.Error(w, "invalid or expired token", http.StatusBadRequest)
+		return
+	}
+	ok, err := store.MarkEmailVerified(r.Context(), h.db, userID, email)
+	if err != nil {
+		http.Error(w, "failed to verify email", http.StatusInternalServerError)
+		return
+	}
+	if !ok {
+		http.Error(w, "invalid or expired token", http.StatusBadRequest)
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]any{"emailVerified": true})
+}
+
+func (h *AuthHandler) ResendVerification(w http.ResponseWriter, r *http.Request) {
+	uid, ok := middleware.UserIDFromContext(r)
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	u, err := store.GetUserByID(r.Context(), h.db, uuid.MustParse(uid))
+	if err != nil {
+		http.Error(w, "Not Found", http.StatusNotFound)
+		return
+	}
+	if u.EmailVerifiedAt != nil {
+		http.Error(w, "email already verified", http.StatusConflict)
+		return
+	}
+	if err := h.sendVerificationEmail(r.Context(), u); err != nil {
+		log.Println("send verification email: ", err)
+		http.Error(w, "failed to send email", http.StatusBadGateway)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
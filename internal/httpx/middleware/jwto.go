@@ -2,17 +2,39 @@ package middleware
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"net/http"
 	"strings"
+	"time"
 
+	"github.com/gabrieldemesio/solo-leveling-go-mvp-v2/internal/auth/signing"
+	"github.com/gabrieldemesio/solo-leveling-go-mvp-v2/internal/store"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type ctxKey string
 
-const UserIDKey ctxKey = "uid"
+const (
+	UserIDKey ctxKey = "uid"
+	TokenKey  ctxKey = "token"
+	ScopesKey ctxKey = "scopes"
+	RoleKey   ctxKey = "role"
+)
+
+// PATPrefix marca os personal access tokens, pra diferenciar de JWT no header Authorization.
+const PATPrefix = "slp_"
 
-func JWTMiddleware(secret []byte) func(http.Handler) http.Handler {
+// TokenInfo descreve o access token que autenticou o request.
+type TokenInfo struct {
+	ID        string
+	SessionID uuid.UUID
+	ExpiresAt time.Time
+}
+
+func JWTMiddleware(keys *signing.KeySet, db *pgxpool.Pool) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			auth := r.Header.Get("Authorization")
@@ -21,24 +43,60 @@ func JWTMiddleware(secret []byte) func(http.Handler) http.Handler {
 				return
 			}
 			tokenStr := strings.TrimPrefix(auth, "Bearer ")
+			if strings.HasPrefix(tokenStr, PATPrefix) {
+				servePAT(w, r, next, db, tokenStr)
+				return
+			}
 			claims := jwt.MapClaims{}
-			_, err := jwt.ParseWithClaims(tokenStr, claims, func(token *jwt.Token) (interface{}, error) {
-				return secret, nil
-			})
+			err := keys.Parse(tokenStr, claims, jwt.WithIssuer("solo-leveling"), jwt.WithAudience(signing.AudienceAccess))
+			// as mesmas chaves assinam outros tipos de token (verificação de e-mail etc.), só aceita access
+			if err != nil || claims["typ"] != "access" {
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+			uid, _ := claims["sub"].(string)
+			userID, err := uuid.Parse(uid)
 			if err != nil {
 				http.Error(w, "unauthorized", http.StatusUnauthorized)
 				return
 			}
-			uid := claims["sub"].(string)
-			if uid == "" {
+			jti, _ := claims["jti"].(string)
+			gen, hasGen := claims["gen"].(float64)
+			sid, _ := claims["sid"].(string)
+			sessionID, sidErr := uuid.Parse(sid)
+			exp, err := claims.GetExpirationTime()
+			if jti == "" || !hasGen || sidErr != nil || err != nil || exp == nil {
 				http.Error(w, "unauthorized", http.StatusUnauthorized)
 				return
 			}
+			valid, err := store.AccessTokenValid(r.Context(), db, userID, jti, int(gen), sessionID)
+			if err != nil || !valid {
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+			// o role do token é confiável: trocar o papel incrementa token_gen e mata os tokens antigos
+			role, _ := claims["role"].(string)
 			ctx := context.WithValue(r.Context(), UserIDKey, uid)
+			ctx = context.WithValue(ctx, TokenKey, TokenInfo{ID: jti, SessionID: sessionID, ExpiresAt: exp.Time})
+			ctx = context.WithValue(ctx, RoleKey, role)
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
 }
+
+// servePAT autentica por personal access token. Só os escopos do token valem (ver RequireScope).
+func servePAT(w http.ResponseWriter, r *http.Request, next http.Handler, db *pgxpool.Pool, raw string) {
+	sum := sha256.Sum256([]byte(raw))
+	t, err := store.UsePersonalAccessToken(r.Context(), db, hex.EncodeToString(sum[:]))
+	if err != nil {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	ctx := context.WithValue(r.Context(), UserIDKey, t.UserID.String())
+	ctx = context.WithValue(ctx, ScopesKey, t.Scopes)
+	next.ServeHTTP(w, r.WithContext(ctx))
+}
+
 func UserIDFromContext(r *http.Request) (string, bool) {
 	v := r.Context().Value(UserIDKey)
 	if v == nil {
@@ -47,3 +105,31 @@ func UserIDFromContext(r *http.Request) (string, bool) {
 	s, _ := v.(string)
 	return s, s != ""
 }
+
+func TokenFromContext(r *http.Request) (TokenInfo, bool) {
+	t, ok := r.Context().Value(TokenKey).(TokenInfo)
+	return t, ok
+}
+
+// RequireVerifiedEmail barra usuários que ainda não confirmaram o e-mail. Precisa rodar depois do JWTMiddleware.
+func RequireVerifiedEmail(db *pgxpool.Pool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			uid, ok := UserIDFromContext(r)
+			if !ok {
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+			verified, err := store.IsEmailVerified(r.Context(), db, uuid.MustParse(uid))
+			if err != nil {
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+			if !verified {
+				http.Error(w, "email not verified", http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRealIP(t *testing.T) {
+	trusted, err := ParseTrustedProxies("10.0.0.0/8, 127.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		name   string
+		remote string
+		xff    []string
+		want   string
+	}{
+		{"direct client ignores header", "203.0.113.9:5000", []string{"1.2.3.4"}, "203.0.113.9:5000"},
+		{"trusted proxy without header", "10.0.0.5:443", nil, "10.0.0.5:443"},
+		{"one proxy", "10.0.0.5:443", []string{"198.51.100.7"}, "198.51.100.7"},
+		{"spoofed left entries are skipped", "10.0.0.5:443", []string{"6.6.6.6, 198.51.100.7"}, "198.51.100.7"},
+		{"proxy chain", "127.0.0.1:8080", []string{"198.51.100.7, 10.1.2.3"}, "198.51.100.7"},
+		{"repeated headers", "10.0.0.5:443", []string{"6.6.6.6", "198.51.100.7, 10.9.9.9"}, "198.51.100.7"},
+		{"only proxies in chain", "10.0.0.5:443", []string{"10.1.1.1"}, "10.1.1.1"},
+		{"garbage stops the walk", "10.0.0.5:443", []string{"198.51.100.7, nonsense"}, "10.0.0.5:443"},
+		{"ipv6 client", "10.0.0.5:443", []string{"2001:db8::1"}, "2001:db8::1"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var got string
+			h := RealIP(trusted)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { got = r.RemoteAddr }))
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.RemoteAddr = c.remote
+			for _, v := range c.xff {
+				r.Header.Add("X-Forwarded-For", v)
+			}
+			h.ServeHTTP(httptest.NewRecorder(), r)
+			if got != c.want {
+				t.Fatalf("RemoteAddr = %q, want %q", got, c.want)
+			}
+		})
+	}
+}
+
+func TestParseTrustedProxiesInvalid(t *testing.T) {
+	for _, s := range []string{"10.0.0.0/33", "not-an-ip", "10.0.0.1,,oops"} {
+		if _, err := ParseTrustedProxies(s); err == nil {
+			t.Errorf("ParseTrustedProxies(%q) accepted", s)
+		}
+	}
+}
@@ -0,0 +1,17 @@
+package middleware
+
+import "net/http"
+
+// RequireRole só deixa passar access tokens com o claim role pedido. Personal access tokens não
+// carregam papel nenhum, então nunca passam por aqui. Precisa rodar depois do JWTMiddleware.
+func RequireRole(role string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if got, _ := r.Context().Value(RoleKey).(string); got != role {
+				http.Error(w, "forbidden", http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"net/http"
+	"slices"
+)
+
+const (
+	ScopeProfileRead  = "profile:read"
+	ScopeQuestsRead   = "quests:read"
+	ScopeQuestsWrite  = "quests:write"
+	ScopeGatesWrite   = "gates:write"
+	ScopeItemsWrite   = "items:write"
+	ScopeRewardsRead  = "rewards:read"
+	ScopeRewardsWrite = "rewards:write"
+	// ScopeAccount cobre senha, 2FA, tokens e logout. Nunca é concedido a personal access tokens.
+	ScopeAccount = "account"
+)
+
+// PATScopes são os escopos que um personal access token pode receber.
+var PATScopes = []string{ScopeProfileRead, ScopeQuestsRead, ScopeQuestsWrite, ScopeGatesWrite, ScopeItemsWrite,
+	ScopeRewardsRead, ScopeRewardsWrite}
+
+// RequireScope barra requests autenticados por personal access token sem o escopo pedido.
+// Sessões normais (JWT do login) não têm escopos no contexto e passam direto.
+func RequireScope(scope string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			scopes, limited := r.Context().Value(ScopesKey).([]string)
+			if limited && !slices.Contains(scopes, scope) {
+				http.Error(w, "insufficient scope", http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
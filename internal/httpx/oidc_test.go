@@ -0,0 +1,187 @@
+package httpx
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"testing"
+
+	"github.com/gabrieldemesio/solo-leveling-go-mvp-v2/internal/auth/oidc"
+	"github.com/gabrieldemesio/solo-leveling-go-mvp-v2/internal/auth/oidc/oidctest"
+	"github.com/gabrieldemesio/solo-leveling-go-mvp-v2/internal/auth/totp"
+	"github.com/gabrieldemesio/solo-leveling-go-mvp-v2/internal/store"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// oidcServer sobe o IdP mock e a API apontando pra ele; cada chamada usa um e-mail novo.
+func oidcServer(t *testing.T, pool *pgxpool.Pool) (api string, email string) {
+	t.Helper()
+	email = "oidc-" + uuid.NewString() + "@example.com"
+	_, idp, err := oidctest.NewServer(email)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(idp.Close)
+	srv := startServer(t, func(base string) http.Handler {
+		cfg := testConfig(t, base)
+		cfg.OIDC = &oidc.Provider{
+			Issuer:      idp.URL,
+			ClientID:    "solo",
+			RedirectURL: base + "/v1/auth/oidc/callback",
+		}
+		return NewServer(pool, cfg)
+	})
+	return srv.URL, email
+}
+
+func TestOIDCLoginIssuesTokens(t *testing.T) {
+	pool := testDB(t)
+	api, email := oidcServer(t, pool)
+
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := &http.Client{Jar: jar}
+	// login → IdP → callback, seguindo os redirects com o cookie do state
+	res, err := client.Get(api + "/v1/auth/oidc/login")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("callback status = %d", res.StatusCode)
+	}
+	var tokens struct {
+		AccessToken  string `json:"accessToken"`
+		RefreshToken string `json:"refreshToken"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&tokens); err != nil || tokens.AccessToken == "" || tokens.RefreshToken == "" {
+		t.Fatalf("tokens = %+v, err = %v", tokens, err)
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, api+"/v1/me", nil)
+	req.Header.Set("Authorization", "Bearer "+tokens.AccessToken)
+	me, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer me.Body.Close()
+	var u struct {
+		Email string `json:"email"`
+	}
+	if err := json.NewDecoder(me.Body).Decode(&u); err != nil || me.StatusCode != http.StatusOK {
+		t.Fatalf("GET /v1/me status = %d, err = %v", me.StatusCode, err)
+	}
+	if u.Email != email {
+		t.Fatalf("email = %q, want %q", u.Email, email)
+	}
+}
+
+func TestOIDCCallbackRequiresStateCookie(t *testing.T) {
+	pool := testDB(t)
+	api, _ := oidcServer(t, pool)
+
+	// o atacante começa o login no navegador dele e entrega o link do callback pra vítima
+	noRedirect := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	callback := api + "/v1/auth/oidc/login"
+	for i := 0; i < 2; i++ {
+		res, err := noRedirect.Get(callback)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+		if res.StatusCode != http.StatusFound {
+			t.Fatalf("step %d status = %d", i, res.StatusCode)
+		}
+		callback = res.Header.Get("Location")
+	}
+	u, err := url.Parse(callback)
+	if err != nil || u.Query().Get("state") == "" {
+		t.Fatalf("unexpected callback url %q", callback)
+	}
+	res, err := http.Get(callback)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusBadRequest {
+		t.Fatalf("callback without cookie status = %d, want 400", res.StatusCode)
+	}
+}
+
+// oidcLogin faz o fluxo inteiro num navegador novo e devolve o JSON do callback.
+func oidcLogin(t *testing.T, api string) map[string]any {
+	t.Helper()
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := (&http.Client{Jar: jar}).Get(api + "/v1/auth/oidc/login")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("callback status = %d", res.StatusCode)
+	}
+	out := map[string]any{}
+	if err := json.NewDecoder(res.Body).Decode(&out); err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+func TestOIDCLoginRequiresMFA(t *testing.T) {
+	pool := testDB(t)
+	api, email := oidcServer(t, pool)
+	ctx := context.Background()
+
+	// primeiro login cria a conta; depois liga o 2FA com um código de recuperação conhecido
+	if out := oidcLogin(t, api); out["accessToken"] == nil {
+		t.Fatalf("first login = %v", out)
+	}
+	u, err := store.GetUserByEmail(ctx, pool, email)
+	if err != nil {
+		t.Fatal(err)
+	}
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.StartTOTPEnrollment(ctx, pool, u.ID, secret); err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256([]byte("abcde12345"))
+	if err := store.EnableTOTP(ctx, pool, u.ID, 0, []string{hex.EncodeToString(sum[:])}); err != nil {
+		t.Fatal(err)
+	}
+
+	out := oidcLogin(t, api)
+	if out["accessToken"] != nil || out["refreshToken"] != nil {
+		t.Fatalf("tokens issued without second factor: %v", out)
+	}
+	challenge, _ := out["mfaToken"].(string)
+	if out["mfaRequired"] != true || challenge == "" {
+		t.Fatalf("callback = %v, want mfa challenge", out)
+	}
+
+	body, _ := json.Marshal(map[string]string{"mfaToken": challenge, "recoveryCode": "abcde-12345"})
+	res, err := http.Post(api+"/v1/auth/mfa", "application/json", bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	var tokens struct {
+		AccessToken string `json:"accessToken"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&tokens); err != nil || res.StatusCode != http.StatusOK || tokens.AccessToken == "" {
+		t.Fatalf("POST /v1/auth/mfa status = %d, err = %v", res.StatusCode, err)
+	}
+}
@@ -1,20 +1,51 @@
 package httpx
 
 import (
+	"net"
 	"net/http"
 
+	"github.com/gabrieldemesio/solo-leveling-go-mvp-v2/internal/auth/oidc"
+	"github.com/gabrieldemesio/solo-leveling-go-mvp-v2/internal/auth/password"
+	"github.com/gabrieldemesio/solo-leveling-go-mvp-v2/internal/auth/signing"
+	"github.com/gabrieldemesio/solo-leveling-go-mvp-v2/internal/core/leveling"
+	"github.com/gabrieldemesio/solo-leveling-go-mvp-v2/internal/core/ruleset"
 	"github.com/gabrieldemesio/solo-leveling-go-mvp-v2/internal/httpx/handlers"
 	"github.com/gabrieldemesio/solo-leveling-go-mvp-v2/internal/httpx/middleware"
+	"github.com/gabrieldemesio/solo-leveling-go-mvp-v2/internal/mail"
+	"github.com/gabrieldemesio/solo-leveling-go-mvp-v2/internal/store"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/cors"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
-func NewServer(pool *pgxpool.Pool, jwtSecret []byte) http.Handler {
+type Config struct {
+	Keys   *signing.KeySet
+	Mailer mail.Mailer
+	// PublicURL é a base usada nos links enviados por e-mail.
+	PublicURL string
+	// PasswordResetURL é a página do frontend pro link de reset; vazio usa a da API.
+	PasswordResetURL string
+	// RequireVerifiedEmail barra a abertura de gates até o usuário confirmar o e-mail.
+	RequireVerifiedEmail bool
+	PasswordHasher       password.Hasher
+	PasswordPolicy       *password.Policy
+	// OIDC liga o login pelo IdP da empresa; nil desliga.
+	OIDC                  *oidc.Provider
+	OIDCPostLoginRedirect string
+	// LevelCurve define quanto XP cada nível custa.
+	LevelCurve leveling.Curve
+	// Rules é o ruleset de recompensas em uso; main troca ele no SIGHUP.
+	Rules *ruleset.Holder
+	// TrustedProxies são os proxies cujo X-Forwarded-For vale como IP do cliente; vazio usa só o RemoteAddr.
+	TrustedProxies []*net.IPNet
+}
+
+func NewServer(pool *pgxpool.Pool, cfg Config) http.Handler {
 	r := chi.NewRouter()
+	r.Use(middleware.RealIP(cfg.TrustedProxies))
 	r.Use(cors.Handler(cors.Options{
 		AllowedOrigins:   []string{"*"},
-		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+		AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
 		AllowedHeaders:   []string{"*"},
 		AllowCredentials: false,
 		MaxAge:           300,
@@ -22,30 +53,125 @@ func NewServer(pool *pgxpool.Pool, jwtSecret []byte) http.Handler {
 	r.Get("/healthz", func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 	})
-	auth := handlers.NewAuthHandler(pool, jwtSecret)
-	gate := handlers.NewGateHandler(pool)
+	auth := handlers.NewAuthHandler(pool, handlers.AuthOptions{
+		Keys:             cfg.Keys,
+		Mailer:           cfg.Mailer,
+		PublicURL:        cfg.PublicURL,
+		PasswordResetURL: cfg.PasswordResetURL,
+		Hasher:           cfg.PasswordHasher,
+		Policy:           cfg.PasswordPolicy,
+
+		OIDC:                  cfg.OIDC,
+		OIDCPostLoginRedirect: cfg.OIDCPostLoginRedirect,
+	})
+	gate := handlers.NewGateHandler(pool, cfg.LevelCurve, cfg.Rules)
 	me := handlers.NewMeHandler(pool)
 	quests := handlers.NewQuestsHandler(pool)
+	tokens := handlers.NewTokensHandler(pool)
+	sessions := handlers.NewSessionsHandler(pool)
+	items := handlers.NewItemsHandler(pool)
+	rewards := handlers.NewRewardsHandler(pool)
+	admin := handlers.NewAdminHandler(pool, cfg.LevelCurve)
+	requireAuth := middleware.JWTMiddleware(cfg.Keys, pool)
+	scope := middleware.RequireScope
+	requireVerified := func(next http.Handler) http.Handler { return next }
+	if cfg.RequireVerifiedEmail {
+		requireVerified = middleware.RequireVerifiedEmail(pool)
+	}
+	r.Get("/.well-known/jwks.json", auth.JWKS)
 
 	r.Route("/v1", func(r chi.Router) {
 		r.Route("/auth", func(r chi.Router) {
 			r.Post("/register", auth.Register)
 			r.Post("/login", auth.Login)
+			r.Post("/refresh", auth.Refresh)
+			r.With(requireAuth, scope(middleware.ScopeAccount)).Post("/logout", auth.Logout)
+			r.With(requireAuth, scope(middleware.ScopeAccount)).Post("/logout-all", auth.LogoutAll)
+			r.Get("/verify", auth.VerifyEmail)
+			r.With(requireAuth, scope(middleware.ScopeAccount)).Post("/verify/resend", auth.ResendVerification)
+			r.Post("/forgot", auth.Forgot)
+			r.Get("/reset", auth.ResetPage)
+			r.Post("/reset", auth.Reset)
+			r.Post("/mfa", auth.VerifyMFA)
+			r.Post("/restore", auth.Restore)
+			if cfg.OIDC != nil {
+				r.Get("/oidc/login", auth.OIDCLogin)
+				r.Get("/oidc/callback", auth.OIDCCallback)
+			}
 		})
 		r.Group(func(r chi.Router) {
-			r.Use(middleware.JWTMiddleware(jwtSecret))
+			r.Use(requireAuth)
 
-			r.Get("/me", me.Me)
+			r.With(scope(middleware.ScopeProfileRead)).Get("/me", me.Me)
+			r.With(scope(middleware.ScopeProfileRead)).Get("/me/rank", me.Rank)
+			r.With(scope(middleware.ScopeProfileRead)).Get("/me/ledger", me.Ledger)
+			r.With(scope(middleware.ScopeProfileRead)).Get("/me/items", items.Inventory)
+			r.With(scope(middleware.ScopeItemsWrite)).Post("/me/items/{id}/use", items.Use)
+			r.With(scope(middleware.ScopeItemsWrite)).Post("/me/items/{id}/discard", items.Discard)
+			r.With(scope(middleware.ScopeProfileRead)).Get("/items", items.Catalog)
+			r.Group(func(r chi.Router) {
+				r.Use(scope(middleware.ScopeAccount))
+				r.Get("/me/export", me.Export)
+				r.Post("/me/stats/allocate", me.AllocateStats)
+				r.Post("/me/stats/respec", me.RespecStats)
+				r.Delete("/me", auth.DeleteAccount)
+				r.Post("/me/password", auth.ChangePassword)
+				r.Route("/me/mfa/totp", func(r chi.Router) {
+					r.Post("/", auth.EnrollTOTP)
+					r.Post("/confirm", auth.ConfirmTOTP)
+					r.Delete("/", auth.DisableTOTP)
+				})
+				r.Route("/me/tokens", func(r chi.Router) {
+					r.Get("/", tokens.List)
+					r.Post("/", tokens.Create)
+					r.Delete("/{id}", tokens.Revoke)
+				})
+				r.Route("/me/sessions", func(r chi.Router) {
+					r.Get("/", sessions.List)
+					r.Delete("/{id}", sessions.Revoke)
+				})
+			})
 
 			r.Route("/quests", func(r chi.Router) {
-				r.Get("/", quests.List)
-				r.Post("/", quests.Create)
-				r.Patch("/{id}", quests.Patch)
-				r.Delete("/{id}", quests.Delete)
+				r.With(scope(middleware.ScopeQuestsRead)).Get("/", quests.List)
+				r.Group(func(r chi.Router) {
+					r.Use(scope(middleware.ScopeQuestsWrite))
+					r.Post("/", quests.Create)
+					r.Patch("/{id}", quests.Patch)
+					r.Delete("/{id}", quests.Delete)
+				})
+			})
+			r.Route("/rewards", func(r chi.Router) {
+				r.With(scope(middleware.ScopeRewardsRead)).Get("/", rewards.List)
+				r.With(scope(middleware.ScopeRewardsRead)).Get("/redemptions", rewards.Redemptions)
+				r.Group(func(r chi.Router) {
+					r.Use(scope(middleware.ScopeRewardsWrite))
+					r.Post("/", rewards.Create)
+					r.Patch("/{id}", rewards.Patch)
+					r.Delete("/{id}", rewards.Delete)
+					r.Post("/{id}/redeem", rewards.Redeem)
+				})
 			})
 			r.Route("/gate", func(r chi.Router) {
-				r.Post("/", gate.Open)
+				r.Use(scope(middleware.ScopeGatesWrite))
+				r.With(requireVerified).Post("/", gate.Open)
+				r.Get("/{id}", gate.Get)
 				r.Post("/{id}", gate.Close)
+				r.Post("/{id}/pause", gate.Pause)
+				r.Post("/{id}/resume", gate.Resume)
+			})
+		})
+		r.Route("/admin", func(r chi.Router) {
+			r.Use(requireAuth, middleware.RequireRole(store.RoleAdmin))
+			r.Get("/users", admin.SearchUsers)
+			r.Route("/users/{id}", func(r chi.Router) {
+				r.Get("/", admin.GetUser)
+				r.Get("/ledger", admin.Ledger)
+				r.Post("/ban", admin.Ban)
+				r.Post("/unban", admin.Unban)
+				r.Post("/adjust", admin.Adjust)
+				r.Put("/role", admin.SetRole)
+				r.Post("/password-reset", auth.ForcePasswordReset)
 			})
 		})
 	})
@@ -0,0 +1,175 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var ErrNegativeBalance = errors.New("adjustment would make balance negative")
+
+// UserAdjustment é uma correção manual feita pelo admin. XP e Gold são deltas; Streak, se vier, substitui o valor.
+type UserAdjustment struct {
+	XP     int64
+	Gold   int64
+	Streak *int
+}
+
+// SearchUsers procura por trecho do e-mail ou pelo id exato.
+func SearchUsers(ctx context.Context, db *pgxpool.Pool, q string, limit, offset int) ([]User, error) {
+	pattern := "%" + strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(q) + "%"
+	rows, err := db.Query(ctx, `SELECT `+userColumns+` FROM users
+	WHERE email ILIKE $1 OR id::text = $2
+	ORDER BY created_at DESC LIMIT $3 OFFSET $4`, pattern, q, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []User{}
+	for rows.Next() {
+		u, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, *u)
+	}
+	return list, rows.Err()
+}
+
+// BanUser bane o usuário e derruba todas as sessões; o evento de auditoria vai na mesma transação.
+func BanUser(ctx context.Context, db *pgxpool.Pool, userID uuid.UUID, reason string, e *AuditEvent) error {
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, `UPDATE users SET banned_at=now(), ban_reason=$2 WHERE id=$1`, userID, reason)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	if err := revokeAllUserTokensTx(ctx, tx, userID); err != nil {
+		return err
+	}
+	if err := createAuditEventTx(ctx, tx, e); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func UnbanUser(ctx context.Context, db *pgxpool.Pool, userID uuid.UUID, e *AuditEvent) error {
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, `UPDATE users SET banned_at=NULL, ban_reason=NULL WHERE id=$1`, userID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	if err := createAuditEventTx(ctx, tx, e); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// AdjustUser aplica a correção e registra o evento. xp e gold nunca ficam negativos e o nível
+// acompanha o XP pela curva.
+func AdjustUser(ctx context.Context, db *pgxpool.Pool, userID uuid.UUID, adj UserAdjustment, curve LevelCurve, e *AuditEvent) (*User, error) {
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	var xp, gold int64
+	var streak int
+	if err := tx.QueryRow(ctx, `SELECT xp, gold, streak FROM users WHERE id=$1 FOR UPDATE`, userID).Scan(&xp, &gold, &streak); err != nil {
+		return nil, err
+	}
+	if xp+adj.XP < 0 || gold+adj.Gold < 0 {
+		return nil, ErrNegativeBalance
+	}
+	if adj.Streak != nil {
+		streak = *adj.Streak
+	}
+	if err := postTx(ctx, tx, userID, Posting{Reason: LedgerAdminAdjustment, RefID: &e.ID, XP: adj.XP, Gold: adj.Gold}); err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec(ctx, `UPDATE users SET streak=$2 WHERE id=$1`, userID, streak); err != nil {
+		return nil, err
+	}
+	if _, err := applyLevelTx(ctx, tx, userID, curve, nil); err != nil {
+		return nil, err
+	}
+	if err := createAuditEventTx(ctx, tx, e); err != nil {
+		return nil, err
+	}
+	u, err := scanUser(tx.QueryRow(ctx, `SELECT `+userColumns+` FROM users WHERE id=$1`, userID))
+	if err != nil {
+		return nil, err
+	}
+
+	return u, tx.Commit(ctx)
+}
+
+// SetUserRole troca o papel e incrementa token_gen, pra nenhum access token ficar com o papel antigo.
+func SetUserRole(ctx context.Context, db *pgxpool.Pool, userID uuid.UUID, role string, e *AuditEvent) error {
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, `UPDATE users SET role=$2, token_gen = token_gen + 1 WHERE id=$1`, userID, role)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	if err := createAuditEventTx(ctx, tx, e); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// RequirePasswordReset bloqueia o login por senha até o usuário redefinir e derruba todas as sessões.
+func RequirePasswordReset(ctx context.Context, db *pgxpool.Pool, userID uuid.UUID, e *AuditEvent) error {
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, `UPDATE users SET password_reset_required=true WHERE id=$1`, userID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	if err := revokeAllUserTokensTx(ctx, tx, userID); err != nil {
+		return err
+	}
+	if err := createAuditEventTx(ctx, tx, e); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
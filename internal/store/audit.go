@@ -0,0 +1,49 @@
+package store
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+func CreateAuditEvent(ctx context.Context, db *pgxpool.Pool, e *AuditEvent) error {
+	if e.Detail == nil {
+		e.Detail = map[string]any{}
+	}
+	_, err := db.Exec(ctx, `INSERT INTO audit_events(id, user_id, action, ip, detail, created_at)
+	VALUES($1,$2,$3,$4,$5,$6)`,
+		e.ID, e.UserID, e.Action, e.IP, e.Detail, e.CreatedAt)
+	return err
+}
+
+// createAuditEventTx grava o evento junto com a mudança que ele descreve.
+func createAuditEventTx(ctx context.Context, tx pgx.Tx, e *AuditEvent) error {
+	if e.Detail == nil {
+		e.Detail = map[string]any{}
+	}
+	_, err := tx.Exec(ctx, `INSERT INTO audit_events(id, user_id, action, ip, detail, created_at)
+	VALUES($1,$2,$3,$4,$5,$6)`,
+		e.ID, e.UserID, e.Action, e.IP, e.Detail, e.CreatedAt)
+	return err
+}
+
+func ListAuditEventsByUser(ctx context.Context, db *pgxpool.Pool, userID uuid.UUID) ([]AuditEvent, error) {
+	rows, err := db.Query(ctx, `SELECT id, user_id, action, COALESCE(ip, ''), detail, created_at
+	FROM audit_events WHERE user_id=$1 ORDER BY created_at`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []AuditEvent{}
+	for rows.Next() {
+		var e AuditEvent
+		if err := rows.Scan(&e.ID, &e.UserID, &e.Action, &e.IP, &e.Detail, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		list = append(list, e)
+	}
+	return list, rows.Err()
+}
@@ -0,0 +1,56 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ScheduleUserDeletion marca a conta pra ser apagada em deleteAfter e derruba todas as sessões.
+// Até lá os dados ficam intactos e RestoreUser desfaz tudo.
+func ScheduleUserDeletion(ctx context.Context, db *pgxpool.Pool, userID uuid.UUID, deleteAfter time.Time) error {
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `UPDATE users SET delete_after=$2 WHERE id=$1`, userID, deleteAfter); err != nil {
+		return err
+	}
+	if err := revokeAllUserTokensTx(ctx, tx, userID); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// RestoreUser cancela a exclusão agendada. false se a conta não estava agendada ou o prazo já passou.
+func RestoreUser(ctx context.Context, db *pgxpool.Pool, userID uuid.UUID) (bool, error) {
+	tag, err := db.Exec(ctx, `UPDATE users SET delete_after=NULL WHERE id=$1 AND delete_after > now()`, userID)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// PurgeDeletedUsers apaga de vez as contas com prazo vencido; o resto some pelos ON DELETE CASCADE.
+// O ledger é append-only, então o cascade nele só passa com ledger.allow_purge ligado nesta transação.
+func PurgeDeletedUsers(ctx context.Context, db *pgxpool.Pool) (int64, error) {
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `SELECT set_config('ledger.allow_purge', 'on', true)`); err != nil {
+		return 0, err
+	}
+	tag, err := tx.Exec(ctx, `DELETE FROM users WHERE delete_after <= now()`)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), tx.Commit(ctx)
+}
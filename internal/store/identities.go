@@ -0,0 +1,80 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+func CreateOIDCState(ctx context.Context, db *pgxpool.Pool, state, nonce, verifier string, expiresAt time.Time) error {
+	// aproveita pra limpar logins que nunca voltaram do IdP
+	if _, err := db.Exec(ctx, `DELETE FROM oidc_login_states WHERE expires_at < now()`); err != nil {
+		return err
+	}
+	_, err := db.Exec(ctx, `INSERT INTO oidc_login_states(state, nonce, code_verifier, expires_at) VALUES($1,$2,$3,$4)`,
+		state, nonce, verifier, expiresAt)
+	return err
+}
+
+// ConsumeOIDCState apaga o state (uso único) e devolve o nonce e o code_verifier guardados.
+func ConsumeOIDCState(ctx context.Context, db *pgxpool.Pool, state string) (nonce, verifier string, err error) {
+	err = db.QueryRow(ctx, `DELETE FROM oidc_login_states WHERE state=$1 AND expires_at > now()
+	RETURNING nonce, code_verifier`, state).Scan(&nonce, &verifier)
+	return nonce, verifier, err
+}
+
+// GetUserIDByIdentity acha o usuário ligado à identidade externa e atualiza last_login_at.
+func GetUserIDByIdentity(ctx context.Context, db *pgxpool.Pool, provider, subject string) (uuid.UUID, error) {
+	var id uuid.UUID
+	err := db.QueryRow(ctx, `UPDATE user_identities SET last_login_at=now()
+	WHERE provider=$1 AND subject=$2 RETURNING user_id`, provider, subject).Scan(&id)
+	return id, err
+}
+
+func CreateUserIdentity(ctx context.Context, db *pgxpool.Pool, i *UserIdentity) error {
+	_, err := db.Exec(ctx, `INSERT INTO user_identities(id, user_id, provider, subject, email, created_at, last_login_at)
+	VALUES($1,$2,$3,$4,$5,$6,$7)`,
+		i.ID, i.UserID, i.Provider, i.Subject, i.Email, i.CreatedAt, i.LastLoginAt)
+	return err
+}
+
+// CreateUserWithIdentity cria o usuário e a identidade externa juntos (primeiro login via OIDC).
+func CreateUserWithIdentity(ctx context.Context, db *pgxpool.Pool, u *User, i *UserIdentity) error {
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := createUserTx(ctx, tx, u); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, `INSERT INTO user_identities(id, user_id, provider, subject, email, created_at, last_login_at)
+	VALUES($1,$2,$3,$4,$5,$6,$7)`,
+		i.ID, i.UserID, i.Provider, i.Subject, i.Email, i.CreatedAt, i.LastLoginAt); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func ListUserIdentities(ctx context.Context, db *pgxpool.Pool, userID uuid.UUID) ([]UserIdentity, error) {
+	rows, err := db.Query(ctx, `SELECT id, user_id, provider, subject, email, created_at, last_login_at
+	FROM user_identities WHERE user_id=$1 ORDER BY created_at`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []UserIdentity{}
+	for rows.Next() {
+		var i UserIdentity
+		if err := rows.Scan(&i.ID, &i.UserID, &i.Provider, &i.Subject, &i.Email, &i.CreatedAt, &i.LastLoginAt); err != nil {
+			return nil, err
+		}
+		list = append(list, i)
+	}
+	return list, rows.Err()
+}
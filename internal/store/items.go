@@ -0,0 +1,182 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	ErrNotInInventory = errors.New("item not in inventory")
+	ErrEffectActive   = errors.New("an effect of this kind is already active")
+)
+
+func ListItems(ctx context.Context, db *pgxpool.Pool) ([]Item, error) {
+	rows, err := db.Query(ctx, `SELECT id, name, description, rarity, effect, power FROM items ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []Item{}
+	for rows.Next() {
+		var it Item
+		if err := rows.Scan(&it.ID, &it.Name, &it.Description, &it.Rarity, &it.Effect, &it.Power); err != nil {
+			return nil, err
+		}
+		list = append(list, it)
+	}
+	return list, rows.Err()
+}
+
+func ListInventory(ctx context.Context, db *pgxpool.Pool, userID uuid.UUID) ([]InventoryItem, error) {
+	rows, err := db.Query(ctx, `SELECT i.id, i.name, i.description, i.rarity, i.effect, i.power, ui.quantity, ui.updated_at
+	FROM user_items ui JOIN items i ON i.id = ui.item_id
+	WHERE ui.user_id=$1 ORDER BY i.id`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []InventoryItem{}
+	for rows.Next() {
+		var it InventoryItem
+		if err := rows.Scan(&it.ID, &it.Name, &it.Description, &it.Rarity, &it.Effect, &it.Power, &it.Quantity, &it.UpdatedAt); err != nil {
+			return nil, err
+		}
+		list = append(list, it)
+	}
+	return list, rows.Err()
+}
+
+// ListUserEffects devolve os efeitos do usuário; activeOnly filtra os ainda não consumidos.
+func ListUserEffects(ctx context.Context, db *pgxpool.Pool, userID uuid.UUID, activeOnly bool) ([]UserEffect, error) {
+	rows, err := db.Query(ctx, `SELECT id, user_id, item_id, effect, power, created_at, consumed_at, consumed_run_id
+	FROM user_effects WHERE user_id=$1 AND (NOT $2 OR consumed_at IS NULL) ORDER BY created_at`, userID, activeOnly)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []UserEffect{}
+	for rows.Next() {
+		var e UserEffect
+		if err := rows.Scan(&e.ID, &e.UserID, &e.ItemID, &e.Effect, &e.Power, &e.CreatedAt, &e.ConsumedAt, &e.ConsumedRunID); err != nil {
+			return nil, err
+		}
+		list = append(list, e)
+	}
+	return list, rows.Err()
+}
+
+// UseItem tira uma unidade do inventário e ativa o efeito dela. Só um efeito de cada tipo fica
+// ativo por vez, pra duas poções não se acumularem no mesmo gate.
+func UseItem(ctx context.Context, db *pgxpool.Pool, userID uuid.UUID, itemID string) (*UserEffect, error) {
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	e := UserEffect{ID: uuid.New(), UserID: userID, ItemID: itemID, CreatedAt: time.Now()}
+	err = tx.QueryRow(ctx, `SELECT i.effect, i.power FROM user_items ui JOIN items i ON i.id = ui.item_id
+	WHERE ui.user_id=$1 AND ui.item_id=$2 FOR UPDATE OF ui`, userID, itemID).Scan(&e.Effect, &e.Power)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotInInventory
+	}
+	if err != nil {
+		return nil, err
+	}
+	var active bool
+	if err := tx.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM user_effects WHERE user_id=$1 AND effect=$2 AND consumed_at IS NULL)`,
+		userID, e.Effect).Scan(&active); err != nil {
+		return nil, err
+	}
+	if active {
+		return nil, ErrEffectActive
+	}
+	if err := removeItemTx(ctx, tx, userID, itemID, 1); err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec(ctx, `INSERT INTO user_effects(id, user_id, item_id, effect, power, created_at) VALUES($1,$2,$3,$4,$5,$6)`,
+		e.ID, e.UserID, e.ItemID, e.Effect, e.Power, e.CreatedAt); err != nil {
+		return nil, err
+	}
+
+	return &e, tx.Commit(ctx)
+}
+
+// DiscardItem joga fora quantity unidades; ErrNotInInventory se o usuário não tiver tudo isso.
+func DiscardItem(ctx context.Context, db *pgxpool.Pool, userID uuid.UUID, itemID string, quantity int) error {
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := removeItemTx(ctx, tx, userID, itemID, quantity); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func removeItemTx(ctx context.Context, tx pgx.Tx, userID uuid.UUID, itemID string, quantity int) error {
+	var left int
+	err := tx.QueryRow(ctx, `UPDATE user_items SET quantity = quantity - $3, updated_at=now()
+	WHERE user_id=$1 AND item_id=$2 AND quantity >= $3
+	RETURNING quantity`, userID, itemID, quantity).Scan(&left)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrNotInInventory
+	}
+	if err != nil {
+		return err
+	}
+	if left == 0 {
+		_, err = tx.Exec(ctx, `DELETE FROM user_items WHERE user_id=$1 AND item_id=$2`, userID, itemID)
+	}
+	return err
+}
+
+// grantItemsTx põe os itens no inventário, uma unidade por entrada. Id fora do catálogo (ruleset com
+// typo) é ignorado em vez de derrubar a recompensa inteira.
+func grantItemsTx(ctx context.Context, tx pgx.Tx, userID uuid.UUID, itemIDs []string) error {
+	for _, id := range itemIDs {
+		if _, err := tx.Exec(ctx, `INSERT INTO user_items(user_id, item_id, quantity)
+		SELECT $1::uuid, id, 1 FROM items WHERE id=$2
+		ON CONFLICT (user_id, item_id) DO UPDATE SET quantity = user_items.quantity + 1, updated_at=now()`,
+			userID, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// activeEffectsTx trava e devolve os efeitos ativos de um tipo, do mais antigo pro mais novo.
+func activeEffectsTx(ctx context.Context, tx pgx.Tx, userID uuid.UUID, effect string) ([]UserEffect, error) {
+	rows, err := tx.Query(ctx, `SELECT id, user_id, item_id, effect, power, created_at
+	FROM user_effects WHERE user_id=$1 AND effect=$2 AND consumed_at IS NULL
+	ORDER BY created_at FOR UPDATE`, userID, effect)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []UserEffect{}
+	for rows.Next() {
+		var e UserEffect
+		if err := rows.Scan(&e.ID, &e.UserID, &e.ItemID, &e.Effect, &e.Power, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		list = append(list, e)
+	}
+	return list, rows.Err()
+}
+
+func consumeEffectTx(ctx context.Context, tx pgx.Tx, effectID uuid.UUID, runID *uuid.UUID) error {
+	_, err := tx.Exec(ctx, `UPDATE user_effects SET consumed_at=now(), consumed_run_id=$2 WHERE id=$1`, effectID, runID)
+	return err
+}
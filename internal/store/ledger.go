@@ -0,0 +1,143 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Reasons do ledger. Cada uma tem a sua conta de sistema ("system:<reason>") como contrapartida.
+const (
+	LedgerOpeningBalance  = "opening_balance"
+	LedgerGateReward      = "gate_reward"
+	LedgerShopRedemption  = "shop_redemption"
+	LedgerStatRespec      = "stat_respec"
+	LedgerAdminAdjustment = "admin_adjustment"
+)
+
+// LedgerAccountUser é a conta do próprio usuário; a soma dela é o saldo em users.
+const LedgerAccountUser = "user"
+
+// Posting é uma movimentação no saldo do usuário: XP e Gold positivos creditam, negativos debitam.
+// RefID aponta pro que causou a movimentação (gate, resgate, audit event do admin...).
+type Posting struct {
+	Reason string
+	RefID  *uuid.UUID
+	XP     int64
+	Gold   int64
+}
+
+// postTx grava a movimentação no ledger (perna do usuário + contrapartida) e atualiza o saldo em cache
+// em users. Todo xp/gold que muda passa por aqui, com a linha do usuário já travada pelo chamador.
+func postTx(ctx context.Context, tx pgx.Tx, userID uuid.UUID, p Posting) error {
+	if p.XP == 0 && p.Gold == 0 {
+		return nil
+	}
+	txID := uuid.New()
+	now := time.Now()
+	for _, leg := range []struct {
+		currency string
+		amount   int64
+	}{{"xp", p.XP}, {"gold", p.Gold}} {
+		if leg.amount == 0 {
+			continue
+		}
+		if _, err := tx.Exec(ctx, `INSERT INTO ledger_entries(tx_id, user_id, account, currency, amount, reason, ref_id, created_at)
+		VALUES($1,$2,$3,$4,$5,$7,$8,$9), ($1,$2,$6,$4,-$5::bigint,$7,$8,$9)`,
+			txID, userID, LedgerAccountUser, leg.currency, leg.amount, "system:"+p.Reason, p.Reason, p.RefID, now); err != nil {
+			return err
+		}
+	}
+	_, err := tx.Exec(ctx, `UPDATE users SET xp = xp + $2, gold = gold + $3 WHERE id=$1`, userID, p.XP, p.Gold)
+	return err
+}
+
+// ListLedgerEntries devolve as pernas do usuário (account='user'), da mais nova pra mais antiga.
+func ListLedgerEntries(ctx context.Context, db *pgxpool.Pool, userID uuid.UUID, limit, offset int) ([]LedgerEntry, error) {
+	rows, err := db.Query(ctx, `SELECT id, tx_id, user_id, account, currency, amount, reason, ref_id, created_at
+	FROM ledger_entries WHERE user_id=$1 AND account=$2
+	ORDER BY created_at DESC, id DESC LIMIT $3 OFFSET $4`, userID, LedgerAccountUser, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []LedgerEntry{}
+	for rows.Next() {
+		var e LedgerEntry
+		if err := rows.Scan(&e.ID, &e.TxID, &e.UserID, &e.Account, &e.Currency, &e.Amount, &e.Reason, &e.RefID, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		list = append(list, e)
+	}
+	return list, rows.Err()
+}
+
+// BalanceMismatch é um usuário cujo saldo em cache não bate com a soma do ledger.
+type BalanceMismatch struct {
+	UserID     uuid.UUID
+	XP, Gold   int64
+	LedgerXP   int64
+	LedgerGold int64
+}
+
+// LedgerReport é o resultado da reconciliação. Vazio = tudo certo.
+type LedgerReport struct {
+	Mismatches []BalanceMismatch
+	// Unbalanced são movimentações cujas pernas não somam zero.
+	Unbalanced []uuid.UUID
+}
+
+func (r *LedgerReport) OK() bool {
+	return len(r.Mismatches) == 0 && len(r.Unbalanced) == 0
+}
+
+// ReconcileLedger confere se users.xp/users.gold batem com o ledger e se toda movimentação fecha em zero.
+// Não corrige nada: divergência é bug e precisa ser investigada.
+func ReconcileLedger(ctx context.Context, db *pgxpool.Pool) (*LedgerReport, error) {
+	rep := &LedgerReport{}
+	rows, err := db.Query(ctx, `SELECT u.id, u.xp, u.gold, COALESCE(l.xp, 0), COALESCE(l.gold, 0)
+	FROM users u
+	LEFT JOIN (
+		SELECT user_id,
+			sum(amount) FILTER (WHERE currency='xp')::bigint AS xp,
+			sum(amount) FILTER (WHERE currency='gold')::bigint AS gold
+		FROM ledger_entries WHERE account=$1 GROUP BY user_id
+	) l ON l.user_id = u.id
+	WHERE u.xp <> COALESCE(l.xp, 0) OR u.gold <> COALESCE(l.gold, 0)
+	ORDER BY u.id`, LedgerAccountUser)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var m BalanceMismatch
+		if err := rows.Scan(&m.UserID, &m.XP, &m.Gold, &m.LedgerXP, &m.LedgerGold); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		rep.Mismatches = append(rep.Mismatches, m)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = db.Query(ctx, `SELECT DISTINCT tx_id FROM (
+		SELECT tx_id FROM ledger_entries GROUP BY tx_id, currency HAVING sum(amount) <> 0
+	) t ORDER BY tx_id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		rep.Unbalanced = append(rep.Unbalanced, id)
+	}
+	return rep, rows.Err()
+}
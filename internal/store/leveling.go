@@ -0,0 +1,172 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ErrRelevelRunning: outra réplica já está rodando o RelevelUsers.
+var ErrRelevelRunning = errors.New("relevel already running")
+
+// relevelLockKey é a chave do advisory lock do relevel, fora do espaço das chaves do worker.
+const relevelLockKey int64 = 0x736c72656c76 // "slrelv"
+
+// LevelCurve é o que o store precisa da curva de níveis (leveling.Curve implementa).
+type LevelCurve interface {
+	LevelFor(xp int64) int
+	StatPointsFor(from, to int) int
+}
+
+// applyLevelTx recalcula o nível pelo XP acumulado, com a linha do usuário já travada pelo chamador.
+// Se subiu (um ou vários níveis) grava o evento e devolve ele; pontos de stat só saem pelos níveis
+// acima do pico (peak_level), senão tirar e devolver XP renderia pontos de novo. Se o XP caiu
+// (correção do admin) o nível desce junto, sem evento e sem tirar pontos.
+func applyLevelTx(ctx context.Context, tx pgx.Tx, userID uuid.UUID, curve LevelCurve, runID *uuid.UUID) (*LevelUpEvent, error) {
+	var level, peak int
+	var xp int64
+	if err := tx.QueryRow(ctx, `SELECT level, peak_level, xp FROM users WHERE id=$1`, userID).Scan(&level, &peak, &xp); err != nil {
+		return nil, err
+	}
+	newLevel := curve.LevelFor(xp)
+	if newLevel == level {
+		return nil, nil
+	}
+	if _, err := tx.Exec(ctx, `UPDATE users SET level=$2, peak_level=GREATEST(peak_level, $2) WHERE id=$1`,
+		userID, newLevel); err != nil {
+		return nil, err
+	}
+	if newLevel < level {
+		return nil, nil
+	}
+	e := LevelUpEvent{
+		ID:         uuid.New(),
+		UserID:     userID,
+		RunID:      runID,
+		FromLevel:  level,
+		ToLevel:    newLevel,
+		TotalXP:    xp,
+		StatPoints: curve.StatPointsFor(max(level, peak), newLevel),
+		CreatedAt:  time.Now(),
+	}
+	if _, err := tx.Exec(ctx, `UPDATE users SET stat_points = stat_points + $2 WHERE id=$1`, userID, e.StatPoints); err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec(ctx, `INSERT INTO level_up_events(id, user_id, run_id, from_level, to_level, total_xp, stat_points, created_at)
+	VALUES($1,$2,$3,$4,$5,$6,$7,$8)`,
+		e.ID, e.UserID, e.RunID, e.FromLevel, e.ToLevel, e.TotalXP, e.StatPoints, e.CreatedAt); err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+// RelevelUsers recalcula o nível de todo mundo pela curva dada (curva trocada em LEVEL_CURVE_* ou XP
+// antigo sem nível), com as mesmas regras do applyLevelTx. Devolve quantos usuários mudaram de nível.
+// Só uma réplica roda por vez, presa num advisory lock; as outras recebem ErrRelevelRunning na hora.
+func RelevelUsers(ctx context.Context, db *pgxpool.Pool, curve LevelCurve) (int, error) {
+	conn, err := db.Acquire(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Release()
+
+	// lock de sessão: fica preso nessa conexão até o unlock (ou até ela cair)
+	var locked bool
+	if err := conn.QueryRow(ctx, `SELECT pg_try_advisory_lock($1)`, relevelLockKey).Scan(&locked); err != nil {
+		return 0, err
+	}
+	if !locked {
+		return 0, ErrRelevelRunning
+	}
+	defer conn.Exec(context.Background(), `SELECT pg_advisory_unlock($1)`, relevelLockKey)
+
+	return relevelAll(ctx, db, curve)
+}
+
+func relevelAll(ctx context.Context, db *pgxpool.Pool, curve LevelCurve) (int, error) {
+	const batch = 500
+	changed := 0
+	after := uuid.Nil
+	for {
+		rows, err := db.Query(ctx, `SELECT id, level, xp FROM users WHERE id > $1 ORDER BY id LIMIT $2`, after, batch)
+		if err != nil {
+			return changed, err
+		}
+		var stale []uuid.UUID
+		n := 0
+		for rows.Next() {
+			var id uuid.UUID
+			var level int
+			var xp int64
+			if err := rows.Scan(&id, &level, &xp); err != nil {
+				rows.Close()
+				return changed, err
+			}
+			n++
+			after = id
+			if curve.LevelFor(xp) != level {
+				stale = append(stale, id)
+			}
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return changed, err
+		}
+		for _, id := range stale {
+			ok, err := relevelUser(ctx, db, id, curve)
+			if err != nil {
+				return changed, err
+			}
+			if ok {
+				changed++
+			}
+		}
+		if n < batch {
+			return changed, nil
+		}
+	}
+}
+
+func relevelUser(ctx context.Context, db *pgxpool.Pool, userID uuid.UUID, curve LevelCurve) (bool, error) {
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback(ctx)
+
+	var level int
+	var xp int64
+	if err := tx.QueryRow(ctx, `SELECT level, xp FROM users WHERE id=$1 FOR UPDATE`, userID).Scan(&level, &xp); err != nil {
+		return false, err
+	}
+	if curve.LevelFor(xp) == level {
+		return false, nil
+	}
+	if _, err := applyLevelTx(ctx, tx, userID, curve, nil); err != nil {
+		return false, err
+	}
+	return true, tx.Commit(ctx)
+}
+
+func ListLevelUpEvents(ctx context.Context, db *pgxpool.Pool, userID uuid.UUID) ([]LevelUpEvent, error) {
+	rows, err := db.Query(ctx, `SELECT id, user_id, run_id, from_level, to_level, total_xp, stat_points, created_at
+	FROM level_up_events WHERE user_id=$1 ORDER BY created_at`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []LevelUpEvent{}
+	for rows.Next() {
+		var e LevelUpEvent
+		if err := rows.Scan(&e.ID, &e.UserID, &e.RunID, &e.FromLevel, &e.ToLevel, &e.TotalXP, &e.StatPoints, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		list = append(list, e)
+	}
+	return list, rows.Err()
+}
@@ -0,0 +1,114 @@
+package store
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var ErrMFAAlreadyEnabled = errors.New("mfa already enabled")
+
+// StartTOTPEnrollment grava um segredo pendente; só vira ativo depois de EnableTOTP.
+// Refazer o enroll troca o segredo pendente, mas nunca um já ativo.
+func StartTOTPEnrollment(ctx context.Context, db *pgxpool.Pool, userID uuid.UUID, secret string) error {
+	tag, err := db.Exec(ctx, `INSERT INTO user_mfa(user_id, totp_secret) VALUES($1,$2)
+	ON CONFLICT (user_id) DO UPDATE SET totp_secret=EXCLUDED.totp_secret, last_used_step=0, created_at=now()
+	WHERE user_mfa.enabled_at IS NULL`, userID, secret)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrMFAAlreadyEnabled
+	}
+	return nil
+}
+
+func GetUserMFA(ctx context.Context, db *pgxpool.Pool, userID uuid.UUID) (*UserMFA, error) {
+	var m UserMFA
+	if err := db.QueryRow(ctx, `SELECT user_id, totp_secret, enabled_at, last_used_step, created_at
+	FROM user_mfa WHERE user_id=$1`, userID).Scan(&m.UserID, &m.TOTPSecret, &m.EnabledAt, &m.LastUsedStep, &m.CreatedAt); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+func MFAEnabled(ctx context.Context, db *pgxpool.Pool, userID uuid.UUID) (bool, error) {
+	var ok bool
+	err := db.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM user_mfa WHERE user_id=$1 AND enabled_at IS NOT NULL)`, userID).Scan(&ok)
+	return ok, err
+}
+
+// EnableTOTP ativa o segredo pendente e troca os códigos de recuperação.
+func EnableTOTP(ctx context.Context, db *pgxpool.Pool, userID uuid.UUID, step int64, recoveryHashes []string) error {
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, `UPDATE user_mfa SET enabled_at=now(), last_used_step=$2
+	WHERE user_id=$1 AND enabled_at IS NULL`, userID, step)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrMFAAlreadyEnabled
+	}
+	if err := replaceRecoveryCodesTx(ctx, tx, userID, recoveryHashes); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func replaceRecoveryCodesTx(ctx context.Context, tx pgx.Tx, userID uuid.UUID, hashes []string) error {
+	if _, err := tx.Exec(ctx, `DELETE FROM mfa_recovery_codes WHERE user_id=$1`, userID); err != nil {
+		return err
+	}
+	for _, h := range hashes {
+		if _, err := tx.Exec(ctx, `INSERT INTO mfa_recovery_codes(id, user_id, code_hash) VALUES($1,$2,$3)`,
+			uuid.New(), userID, h); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UseTOTPStep grava o passo usado; false se ele (ou um mais novo) já tinha sido usado, ou seja, replay.
+func UseTOTPStep(ctx context.Context, db *pgxpool.Pool, userID uuid.UUID, step int64) (bool, error) {
+	tag, err := db.Exec(ctx, `UPDATE user_mfa SET last_used_step=$2 WHERE user_id=$1 AND last_used_step < $2`, userID, step)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// UseRecoveryCode queima um código de recuperação; false se não existe ou já foi usado.
+func UseRecoveryCode(ctx context.Context, db *pgxpool.Pool, userID uuid.UUID, codeHash string) (bool, error) {
+	tag, err := db.Exec(ctx, `UPDATE mfa_recovery_codes SET used_at=now()
+	WHERE user_id=$1 AND code_hash=$2 AND used_at IS NULL`, userID, codeHash)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+func DisableTOTP(ctx context.Context, db *pgxpool.Pool, userID uuid.UUID) error {
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `DELETE FROM user_mfa WHERE user_id=$1`, userID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM mfa_recovery_codes WHERE user_id=$1`, userID); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS sessions (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    user_agent TEXT,
+    ip TEXT,
+    current_jti TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    last_seen_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    revoked_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_sessions_user ON sessions(user_id);
+
+-- cada família de refresh token já existente vira uma sessão, pra ninguém ser deslogado no deploy
+INSERT INTO sessions(id, user_id, created_at, last_seen_at)
+SELECT family_id, user_id, min(created_at), max(created_at)
+FROM refresh_tokens
+WHERE revoked_at IS NULL
+GROUP BY family_id, user_id
+ON CONFLICT (id) DO NOTHING;
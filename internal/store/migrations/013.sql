@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS level_up_events (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    run_id UUID REFERENCES focus_runs(id) ON DELETE SET NULL,
+    from_level INT NOT NULL,
+    to_level INT NOT NULL,
+    total_xp BIGINT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_level_up_events_user ON level_up_events(user_id);
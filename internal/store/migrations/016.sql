@@ -0,0 +1,5 @@
+ALTER TABLE focus_runs ADD COLUMN IF NOT EXISTS ruleset_version TEXT;
+
+-- tudo que existe até aqui foi calculado com os valores fixos do código, que viraram a versão "1"
+UPDATE focus_runs SET ruleset_version = '1' WHERE ruleset_version IS NULL;
+ALTER TABLE focus_runs ALTER COLUMN ruleset_version SET NOT NULL;
@@ -0,0 +1,3 @@
+-- seed do RNG do loot, sorteado na abertura do gate; runs antigos ficam com 0
+ALTER TABLE focus_runs ADD COLUMN IF NOT EXISTS loot_seed BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE focus_runs ADD COLUMN IF NOT EXISTS loot JSONB;
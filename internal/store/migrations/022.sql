@@ -0,0 +1,4 @@
+-- maior nível já alcançado: só subir acima dele rende pontos de stat, então baixar e
+-- devolver XP (ajuste do admin) não cria pontos do nada
+ALTER TABLE users ADD COLUMN IF NOT EXISTS peak_level INT NOT NULL DEFAULT 1;
+UPDATE users SET peak_level = level WHERE peak_level < level;
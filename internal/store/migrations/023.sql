@@ -0,0 +1,4 @@
+-- o nível nunca foi derivado do XP antes da curva. O backfill não fica aqui pra não manter uma
+-- segunda cópia da curva em SQL: o RelevelUsers da subida da API (ou -relevel) recalcula todo mundo
+-- pela curva configurada.
+SELECT 1;
@@ -0,0 +1,18 @@
+-- DELETE no ledger também é bloqueado. O único caminho é o purge de contas vencidas (PurgeDeletedUsers),
+-- que liga ledger.allow_purge com set_config(..., true) só dentro da própria transação.
+CREATE OR REPLACE FUNCTION ledger_entries_no_delete() RETURNS trigger AS $$
+BEGIN
+    IF current_setting('ledger.allow_purge', true) = 'on' THEN
+        RETURN OLD;
+    END IF;
+    RAISE EXCEPTION 'ledger_entries is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS ledger_entries_no_delete ON ledger_entries;
+CREATE TRIGGER ledger_entries_no_delete BEFORE DELETE ON ledger_entries
+    FOR EACH ROW EXECUTE FUNCTION ledger_entries_no_delete();
+
+DROP TRIGGER IF EXISTS ledger_entries_no_truncate ON ledger_entries;
+CREATE TRIGGER ledger_entries_no_truncate BEFORE TRUNCATE ON ledger_entries
+    FOR EACH STATEMENT EXECUTE FUNCTION ledger_entries_append_only();
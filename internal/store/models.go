@@ -1,22 +1,54 @@
 package store
 
 import (
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
+const (
+	RunKindNormal       = "normal"
+	RunKindReassessment = "reassessment"
+)
+
+const (
+	RunResultSuccess = "success"
+	RunResultAbandon = "abandon"
+	// RunResultExpired é do worker: gate que ficou aberto muito depois do alvo.
+	RunResultExpired = "expired"
+)
+
+const (
+	EffectXPMultiplier = "xp_multiplier"
+	EffectStreakShield = "streak_shield"
+	EffectRankKey      = "rank_key"
+)
+
 type User struct {
-	ID             uuid.UUID      `json:"id"`
-	Email          string         `json:"email"`
-	PassHash       string         `json:"-"`
-	Level          int            `json:"level"`
-	XP             int64          `json:"xp"`
-	Gold           int64          `json:"gold"`
-	Stats          map[string]int `json:"stats"`
-	Streak         int            `json:"streak"`
-	LastActiveDate *time.Time     `json:"lastActiveDate,omitempty"`
-	CreatedAt      time.Time      `json:"createdAt"`
+	ID                    uuid.UUID      `json:"id"`
+	Email                 string         `json:"email"`
+	PassHash              string         `json:"-"`
+	Level                 int            `json:"level"`
+	HunterRank            string         `json:"hunterRank"`
+	XP                    int64          `json:"xp"`
+	Gold                  int64          `json:"gold"`
+	Stats                 map[string]int `json:"stats"`
+	StatPoints            int            `json:"statPoints"`
+	Streak                int            `json:"streak"`
+	LastActiveDate        *time.Time     `json:"lastActiveDate,omitempty"`
+	EmailVerifiedAt       *time.Time     `json:"emailVerifiedAt,omitempty"`
+	DeleteAfter           *time.Time     `json:"deleteAfter,omitempty"`
+	Role                  string         `json:"role"`
+	BannedAt              *time.Time     `json:"bannedAt,omitempty"`
+	BanReason             *string        `json:"banReason,omitempty"`
+	PasswordResetRequired bool           `json:"passwordResetRequired,omitempty"`
+	CreatedAt             time.Time      `json:"createdAt"`
 }
 
 type Quest struct {
@@ -31,15 +63,176 @@ type Quest struct {
 	CreatedAt   time.Time  `json:"createdAt"`
 }
 
+// FocusRun é um gate. RulesetVersion é a versão do ruleset que calculou xp_earned/gold_earned.
+// LootSeed só aparece pro cliente dentro do Loot, depois do gate fechado. Pauses é o histórico de
+// pausas; uma sem EndedAt quer dizer que o gate está pausado agora.
 type FocusRun struct {
+	ID             uuid.UUID       `json:"id"`
+	UserID         uuid.UUID       `json:"userId"`
+	QuestID        *uuid.UUID      `json:"questId,omitempty"`
+	DungeonRank    string          `json:"rank"`
+	Kind           string          `json:"kind"`
+	StartAt        time.Time       `json:"startAt"`
+	EndAt          *time.Time      `json:"endAt,omitempty"`
+	TargetMinutes  int             `json:"targetMinutes"`
+	Result         *string         `json:"result,omitempty"`
+	XPEarned       int64           `json:"xpEarned"`
+	GoldEarned     int64           `json:"goldEarned"`
+	RulesetVersion string          `json:"rulesetVersion"`
+	LootSeed       int64           `json:"-"`
+	Loot           json.RawMessage `json:"loot,omitempty"`
+	Pauses         []FocusRunPause `json:"pauses"`
+}
+
+type FocusRunPause struct {
+	ID        uuid.UUID  `json:"id"`
+	RunID     uuid.UUID  `json:"runId"`
+	StartedAt time.Time  `json:"startedAt"`
+	EndedAt   *time.Time `json:"endedAt,omitempty"`
+}
+
+type RefreshToken struct {
+	ID         uuid.UUID  `json:"id"`
+	UserID     uuid.UUID  `json:"userId"`
+	FamilyID   uuid.UUID  `json:"familyId"`
+	TokenHash  string     `json:"-"`
+	ExpiresAt  time.Time  `json:"expiresAt"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	UsedAt     *time.Time `json:"usedAt,omitempty"`
+	RevokedAt  *time.Time `json:"revokedAt,omitempty"`
+	ReplacedBy *uuid.UUID `json:"replacedBy,omitempty"`
+}
+
+type PasswordResetToken struct {
+	ID        uuid.UUID  `json:"id"`
+	UserID    uuid.UUID  `json:"userId"`
+	TokenHash string     `json:"-"`
+	ExpiresAt time.Time  `json:"expiresAt"`
+	CreatedAt time.Time  `json:"createdAt"`
+	UsedAt    *time.Time `json:"usedAt,omitempty"`
+}
+
+type AuditEvent struct {
+	ID        uuid.UUID      `json:"id"`
+	UserID    *uuid.UUID     `json:"userId,omitempty"`
+	Action    string         `json:"action"`
+	IP        string         `json:"ip,omitempty"`
+	Detail    map[string]any `json:"detail,omitempty"`
+	CreatedAt time.Time      `json:"createdAt"`
+}
+
+// UserIdentity liga uma conta externa (issuer OIDC + sub) a um usuário.
+type UserIdentity struct {
+	ID          uuid.UUID  `json:"id"`
+	UserID      uuid.UUID  `json:"userId"`
+	Provider    string     `json:"provider"`
+	Subject     string     `json:"subject"`
+	Email       *string    `json:"email,omitempty"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	LastLoginAt *time.Time `json:"lastLoginAt,omitempty"`
+}
+
+type UserMFA struct {
+	UserID       uuid.UUID  `json:"userId"`
+	TOTPSecret   string     `json:"-"`
+	EnabledAt    *time.Time `json:"enabledAt,omitempty"`
+	LastUsedStep int64      `json:"-"`
+	CreatedAt    time.Time  `json:"createdAt"`
+}
+
+type PersonalAccessToken struct {
+	ID         uuid.UUID  `json:"id"`
+	UserID     uuid.UUID  `json:"userId"`
+	Name       string     `json:"name"`
+	TokenHash  string     `json:"-"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expiresAt,omitempty"`
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	RevokedAt  *time.Time `json:"revokedAt,omitempty"`
+}
+
+// Session é um login (um dispositivo). O id é o mesmo da família de refresh tokens.
+type Session struct {
+	ID         uuid.UUID  `json:"id"`
+	UserID     uuid.UUID  `json:"userId"`
+	UserAgent  *string    `json:"userAgent,omitempty"`
+	IP         *string    `json:"ip,omitempty"`
+	CurrentJTI *string    `json:"-"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	LastSeenAt time.Time  `json:"lastSeenAt"`
+	RevokedAt  *time.Time `json:"revokedAt,omitempty"`
+}
+
+// LevelUpEvent registra cada vez que o usuário subiu de nível (um evento cobre vários níveis de uma vez).
+type LevelUpEvent struct {
+	ID         uuid.UUID  `json:"id"`
+	UserID     uuid.UUID  `json:"userId"`
+	RunID      *uuid.UUID `json:"runId,omitempty"`
+	FromLevel  int        `json:"fromLevel"`
+	ToLevel    int        `json:"toLevel"`
+	TotalXP    int64      `json:"totalXp"`
+	StatPoints int        `json:"statPoints"`
+	CreatedAt  time.Time  `json:"createdAt"`
+}
+
+// Item é uma entrada do catálogo. Effect diz o que acontece quando é usado; Power é a intensidade.
+type Item struct {
+	ID          string  `json:"id"`
+	Name        string  `json:"name"`
+	Description string  `json:"description"`
+	Rarity      string  `json:"rarity"`
+	Effect      string  `json:"effect"`
+	Power       float64 `json:"power"`
+}
+
+type InventoryItem struct {
+	Item
+	Quantity  int       `json:"quantity"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// UserEffect é um item usado esperando o gate que vai consumi-lo.
+type UserEffect struct {
 	ID            uuid.UUID  `json:"id"`
 	UserID        uuid.UUID  `json:"userId"`
-	QuestID       *uuid.UUID `json:"questId,omitempty"`
-	DungeonRank   string     `json:"rank"`
-	StartAt       time.Time  `json:"startAt"`
-	EndAt         *time.Time `json:"endAt,omitempty"`
-	TargetMinutes int        `json:"targetMinutes"`
-	Result        *string    `json:"result,omitempty"`
-	XPEarned      int64      `json:"xpEarned"`
-	GoldEarned    int64      `json:"goldEarned"`
+	ItemID        string     `json:"itemId"`
+	Effect        string     `json:"effect"`
+	Power         float64    `json:"power"`
+	CreatedAt     time.Time  `json:"createdAt"`
+	ConsumedAt    *time.Time `json:"consumedAt,omitempty"`
+	ConsumedRunID *uuid.UUID `json:"consumedRunId,omitempty"`
+}
+
+// ShopReward é uma recompensa da vida real que o usuário compra com gold ("um episódio = 300 gold").
+type ShopReward struct {
+	ID          uuid.UUID `json:"id"`
+	UserID      uuid.UUID `json:"userId"`
+	Title       string    `json:"title"`
+	Description *string   `json:"description,omitempty"`
+	Price       int64     `json:"price"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+type RewardRedemption struct {
+	ID        uuid.UUID  `json:"id"`
+	UserID    uuid.UUID  `json:"userId"`
+	RewardID  *uuid.UUID `json:"rewardId,omitempty"`
+	Title     string     `json:"title"`
+	Price     int64      `json:"price"`
+	CreatedAt time.Time  `json:"createdAt"`
+}
+
+// LedgerEntry é uma perna de uma movimentação de XP ou gold. Amount positivo credita a conta.
+type LedgerEntry struct {
+	ID        int64      `json:"id"`
+	TxID      uuid.UUID  `json:"txId"`
+	UserID    uuid.UUID  `json:"userId"`
+	Account   string     `json:"account"`
+	Currency  string     `json:"currency"`
+	Amount    int64      `json:"amount"`
+	Reason    string     `json:"reason"`
+	RefID     *uuid.UUID `json:"refId,omitempty"`
+	CreatedAt time.Time  `json:"createdAt"`
 }
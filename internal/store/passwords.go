@@ -0,0 +1,77 @@
+package store
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var ErrResetTokenInvalid = errors.New("password reset token invalid or expired")
+
+func CreatePasswordResetToken(ctx context.Context, db *pgxpool.Pool, t *PasswordResetToken) error {
+	_, err := db.Exec(ctx, `INSERT INTO password_reset_tokens(id, user_id, token_hash, expires_at, created_at)
+	VALUES($1,$2,$3,$4,$5)`,
+		t.ID, t.UserID, t.TokenHash, t.ExpiresAt, t.CreatedAt)
+	return err
+}
+
+// ResetPassword consome o token de reset (uso único) e troca a senha na mesma transação.
+// Todos os outros tokens de reset pendentes e todas as sessões do usuário morrem junto.
+func ResetPassword(ctx context.Context, db *pgxpool.Pool, tokenHash, passHash string) (uuid.UUID, error) {
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	var userID uuid.UUID
+	err = tx.QueryRow(ctx, `UPDATE password_reset_tokens SET used_at=now()
+	WHERE token_hash=$1 AND used_at IS NULL AND expires_at > now()
+	RETURNING user_id`, tokenHash).Scan(&userID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return uuid.Nil, ErrResetTokenInvalid
+	}
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if _, err := tx.Exec(ctx, `UPDATE password_reset_tokens SET used_at=now() WHERE user_id=$1 AND used_at IS NULL`, userID); err != nil {
+		return uuid.Nil, err
+	}
+	if err := setPasswordTx(ctx, tx, userID, passHash); err != nil {
+		return uuid.Nil, err
+	}
+
+	return userID, tx.Commit(ctx)
+}
+
+// ChangePassword troca a senha e derruba todas as sessões existentes do usuário.
+func ChangePassword(ctx context.Context, db *pgxpool.Pool, userID uuid.UUID, passHash string) error {
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := setPasswordTx(ctx, tx, userID, passHash); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func setPasswordTx(ctx context.Context, tx pgx.Tx, userID uuid.UUID, passHash string) error {
+	if _, err := tx.Exec(ctx, `UPDATE users SET pass_hash=$2, password_reset_required=false WHERE id=$1`, userID, passHash); err != nil {
+		return err
+	}
+	return revokeAllUserTokensTx(ctx, tx, userID)
+}
+
+// UpgradePasswordHash troca só o formato do hash (rehash no login), sem mexer nas sessões.
+// Se a senha mudou nesse meio tempo o hash antigo não bate e nada é feito.
+func UpgradePasswordHash(ctx context.Context, db *pgxpool.Pool, userID uuid.UUID, oldHash, newHash string) error {
+	_, err := db.Exec(ctx, `UPDATE users SET pass_hash=$3 WHERE id=$1 AND pass_hash=$2`, userID, oldHash, newHash)
+	return err
+}
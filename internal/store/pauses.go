@@ -0,0 +1,104 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	ErrRunClosed     = errors.New("gate already closed")
+	ErrAlreadyPaused = errors.New("gate already paused")
+	ErrNotPaused     = errors.New("gate is not paused")
+	ErrPauseLimit    = errors.New("pause limit reached for this rank")
+)
+
+// PausedTotal soma as pausas; a que ainda está aberta conta até now.
+func PausedTotal(pauses []FocusRunPause, now time.Time) time.Duration {
+	var total time.Duration
+	for _, p := range pauses {
+		end := now
+		if p.EndedAt != nil {
+			end = *p.EndedAt
+		}
+		total += end.Sub(p.StartedAt)
+	}
+	return total
+}
+
+// PauseFocusRun pausa um gate aberto. Recusa se a pausa do rank (maxPause) já foi toda usada.
+func PauseFocusRun(ctx context.Context, db *pgxpool.Pool, runID uuid.UUID, maxPause time.Duration) (*FocusRunPause, error) {
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	var endAt *time.Time
+	if err := tx.QueryRow(ctx, `SELECT end_at FROM focus_runs WHERE id=$1 FOR UPDATE`, runID).Scan(&endAt); err != nil {
+		return nil, err
+	}
+	if endAt != nil {
+		return nil, ErrRunClosed
+	}
+	pauses, err := listPausesTx(ctx, tx, runID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	for _, p := range pauses {
+		if p.EndedAt == nil {
+			return nil, ErrAlreadyPaused
+		}
+	}
+	if PausedTotal(pauses, now) >= maxPause {
+		return nil, ErrPauseLimit
+	}
+	p := FocusRunPause{ID: uuid.New(), RunID: runID, StartedAt: now}
+	if _, err := tx.Exec(ctx, `INSERT INTO focus_run_pauses(id, run_id, started_at) VALUES($1,$2,$3)`,
+		p.ID, p.RunID, p.StartedAt); err != nil {
+		return nil, err
+	}
+
+	return &p, tx.Commit(ctx)
+}
+
+// ResumeFocusRun fecha a pausa aberta do gate; ErrNotPaused se não houver.
+func ResumeFocusRun(ctx context.Context, db *pgxpool.Pool, runID uuid.UUID) (*FocusRunPause, error) {
+	var p FocusRunPause
+	err := db.QueryRow(ctx, `UPDATE focus_run_pauses SET ended_at=now()
+	WHERE run_id=$1 AND ended_at IS NULL
+	RETURNING id, run_id, started_at, ended_at`, runID).Scan(&p.ID, &p.RunID, &p.StartedAt, &p.EndedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotPaused
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+func listPausesTx(ctx context.Context, tx pgx.Tx, runID uuid.UUID) ([]FocusRunPause, error) {
+	rows, err := tx.Query(ctx, `SELECT id, run_id, started_at, ended_at FROM focus_run_pauses WHERE run_id=$1 ORDER BY started_at`, runID)
+	if err != nil {
+		return nil, err
+	}
+	return scanPauses(rows)
+}
+
+func scanPauses(rows pgx.Rows) ([]FocusRunPause, error) {
+	defer rows.Close()
+	list := []FocusRunPause{}
+	for rows.Next() {
+		var p FocusRunPause
+		if err := rows.Scan(&p.ID, &p.RunID, &p.StartedAt, &p.EndedAt); err != nil {
+			return nil, err
+		}
+		list = append(list, p)
+	}
+	return list, rows.Err()
+}
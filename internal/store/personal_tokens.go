@@ -0,0 +1,66 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+func CreatePersonalAccessToken(ctx context.Context, db *pgxpool.Pool, t *PersonalAccessToken) error {
+	_, err := db.Exec(ctx, `INSERT INTO personal_access_tokens(id, user_id, name, token_hash, prefix, scopes, expires_at, created_at)
+	VALUES($1,$2,$3,$4,$5,$6,$7,$8)`,
+		t.ID, t.UserID, t.Name, t.TokenHash, t.Prefix, t.Scopes, t.ExpiresAt, t.CreatedAt)
+	return err
+}
+
+func ListPersonalAccessTokens(ctx context.Context, db *pgxpool.Pool, userID uuid.UUID) ([]PersonalAccessToken, error) {
+	rows, err := db.Query(ctx, `SELECT id, user_id, name, prefix, scopes, expires_at, last_used_at, created_at, revoked_at
+	FROM personal_access_tokens WHERE user_id=$1 AND revoked_at IS NULL ORDER BY created_at DESC`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []PersonalAccessToken{}
+	for rows.Next() {
+		var t PersonalAccessToken
+		if err := rows.Scan(&t.ID, &t.UserID, &t.Name, &t.Prefix, &t.Scopes, &t.ExpiresAt, &t.LastUsedAt,
+			&t.CreatedAt, &t.RevokedAt); err != nil {
+			return nil, err
+		}
+		list = append(list, t)
+	}
+	return list, rows.Err()
+}
+
+// RevokePersonalAccessToken devolve false se o token não existe, não é do usuário ou já estava revogado.
+func RevokePersonalAccessToken(ctx context.Context, db *pgxpool.Pool, userID, id uuid.UUID) (bool, error) {
+	tag, err := db.Exec(ctx, `UPDATE personal_access_tokens SET revoked_at=now()
+	WHERE id=$1 AND user_id=$2 AND revoked_at IS NULL`, id, userID)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// UsePersonalAccessToken acha um token ativo (não revogado nem expirado) e marca o uso.
+// last_used_at só é regravado uma vez por minuto pra não virar um UPDATE por request.
+func UsePersonalAccessToken(ctx context.Context, db *pgxpool.Pool, tokenHash string) (*PersonalAccessToken, error) {
+	var t PersonalAccessToken
+	// conta banida ou com exclusão agendada não usa token nenhum; se for restaurada os tokens voltam a valer
+	if err := db.QueryRow(ctx, `SELECT t.id, t.user_id, t.scopes, t.last_used_at
+	FROM personal_access_tokens t JOIN users u ON u.id = t.user_id
+	WHERE t.token_hash=$1 AND t.revoked_at IS NULL AND (t.expires_at IS NULL OR t.expires_at > now())
+	AND u.delete_after IS NULL AND u.banned_at IS NULL`, tokenHash).Scan(
+		&t.ID, &t.UserID, &t.Scopes, &t.LastUsedAt); err != nil {
+		return nil, err
+	}
+	if t.LastUsedAt == nil || time.Since(*t.LastUsedAt) > time.Minute {
+		if _, err := db.Exec(ctx, `UPDATE personal_access_tokens SET last_used_at=now() WHERE id=$1`, t.ID); err != nil {
+			return nil, err
+		}
+	}
+	return &t, nil
+}
@@ -0,0 +1,78 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	ErrRefreshTokenReused  = errors.New("refresh token reused")
+	ErrRefreshTokenRevoked = errors.New("refresh token revoked")
+	ErrRefreshTokenExpired = errors.New("refresh token expired")
+)
+
+// RotateRefreshToken consome o refresh token com o hash informado e grava next como sucessor
+// na mesma família (UserID e FamilyID de next são preenchidos aqui). A sessão da família passa a
+// apontar pro jti do access token novo.
+// Se o token já tinha sido usado, alguém está reaproveitando um token antigo: a família inteira
+// (e a sessão) é revogada.
+func RotateRefreshToken(ctx context.Context, db *pgxpool.Pool, tokenHash string, next *RefreshToken, jti string) error {
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	var cur RefreshToken
+	if err := tx.QueryRow(ctx, `SELECT id, user_id, family_id, expires_at, used_at, revoked_at
+	FROM refresh_tokens WHERE token_hash=$1 FOR UPDATE`, tokenHash).Scan(
+		&cur.ID, &cur.UserID, &cur.FamilyID, &cur.ExpiresAt, &cur.UsedAt, &cur.RevokedAt); err != nil {
+		return err
+	}
+	next.UserID = cur.UserID
+	next.FamilyID = cur.FamilyID
+
+	if cur.UsedAt != nil {
+		if _, err := tx.Exec(ctx, `UPDATE refresh_tokens SET revoked_at=now() WHERE family_id=$1 AND revoked_at IS NULL`,
+			cur.FamilyID); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx, `UPDATE sessions SET revoked_at=now() WHERE id=$1 AND revoked_at IS NULL`,
+			cur.FamilyID); err != nil {
+			return err
+		}
+		if err := tx.Commit(ctx); err != nil {
+			return err
+		}
+		return ErrRefreshTokenReused
+	}
+	if cur.RevokedAt != nil {
+		return ErrRefreshTokenRevoked
+	}
+	if time.Now().After(cur.ExpiresAt) {
+		return ErrRefreshTokenExpired
+	}
+
+	tag, err := tx.Exec(ctx, `UPDATE sessions SET current_jti=$2, last_seen_at=now() WHERE id=$1 AND revoked_at IS NULL`,
+		cur.FamilyID, jti)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrRefreshTokenRevoked
+	}
+	if _, err := tx.Exec(ctx, `UPDATE refresh_tokens SET used_at=now(), replaced_by=$2 WHERE id=$1`,
+		cur.ID, next.ID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, `INSERT INTO refresh_tokens(id, user_id, family_id, token_hash, expires_at, created_at)
+	VALUES($1,$2,$3,$4,$5,$6)`,
+		next.ID, next.UserID, next.FamilyID, next.TokenHash, next.ExpiresAt, next.CreatedAt); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
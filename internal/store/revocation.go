@@ -0,0 +1,75 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// AccessTokenValid confere se um access token ainda vale: a geração precisa bater com users.token_gen,
+// o jti não pode estar na lista de revogados e a sessão dele tem que estar ativa e com esse jti como
+// o atual (cada refresh troca o current_jti, então o access token anterior da sessão morre junto).
+// De quebra atualiza o last_seen_at da sessão (no máximo uma vez por minuto).
+func AccessTokenValid(ctx context.Context, db *pgxpool.Pool, userID uuid.UUID, jti string, gen int, sessionID uuid.UUID) (bool, error) {
+	row := db.QueryRow(ctx, `WITH touched AS (
+		UPDATE sessions SET last_seen_at=now()
+		WHERE id=$4 AND user_id=$1 AND revoked_at IS NULL AND last_seen_at < now() - interval '1 minute'
+	)
+	SELECT u.token_gen = $3
+		AND NOT EXISTS(SELECT 1 FROM revoked_tokens WHERE jti=$2)
+		AND EXISTS(SELECT 1 FROM sessions s WHERE s.id=$4 AND s.user_id=u.id AND s.revoked_at IS NULL
+			AND s.current_jti=$2)
+	FROM users u WHERE u.id=$1`, userID, jti, gen, sessionID)
+	var ok bool
+	if err := row.Scan(&ok); err != nil {
+		return false, err
+	}
+	return ok, nil
+}
+
+// GetTokenClaims devolve o que vai dentro do access token: a geração atual e o papel do usuário.
+func GetTokenClaims(ctx context.Context, db *pgxpool.Pool, userID uuid.UUID) (gen int, role string, err error) {
+	err = db.QueryRow(ctx, `SELECT token_gen, role FROM users WHERE id=$1`, userID).Scan(&gen, &role)
+	return gen, role, err
+}
+
+// RevokeAccessToken coloca o jti na lista de revogados até ele expirar.
+func RevokeAccessToken(ctx context.Context, db *pgxpool.Pool, userID uuid.UUID, jti string, expiresAt time.Time) error {
+	// aproveita pra limpar o que já expirou, não precisa mais ficar na lista
+	if _, err := db.Exec(ctx, `DELETE FROM revoked_tokens WHERE expires_at < now()`); err != nil {
+		return err
+	}
+	_, err := db.Exec(ctx, `INSERT INTO revoked_tokens(jti, user_id, expires_at) VALUES($1,$2,$3)
+	ON CONFLICT (jti) DO NOTHING`, jti, userID, expiresAt)
+	return err
+}
+
+// RevokeAllUserTokens derruba todas as sessões do usuário: incrementa token_gen (mata os access tokens)
+// e revoga todos os refresh tokens e sessões.
+func RevokeAllUserTokens(ctx context.Context, db *pgxpool.Pool, userID uuid.UUID) error {
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := revokeAllUserTokensTx(ctx, tx, userID); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func revokeAllUserTokensTx(ctx context.Context, tx pgx.Tx, userID uuid.UUID) error {
+	if _, err := tx.Exec(ctx, `UPDATE users SET token_gen = token_gen + 1 WHERE id=$1`, userID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, `UPDATE refresh_tokens SET revoked_at=now() WHERE user_id=$1 AND revoked_at IS NULL`, userID); err != nil {
+		return err
+	}
+	_, err := tx.Exec(ctx, `UPDATE sessions SET revoked_at=now() WHERE user_id=$1 AND revoked_at IS NULL`, userID)
+	return err
+}
@@ -0,0 +1,109 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+func ListShopRewards(ctx context.Context, db *pgxpool.Pool, userID uuid.UUID) ([]ShopReward, error) {
+	rows, err := db.Query(ctx, `SELECT id, user_id, title, description, price, created_at
+	FROM shop_rewards WHERE user_id=$1 ORDER BY price, created_at`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []ShopReward{}
+	for rows.Next() {
+		var s ShopReward
+		if err := rows.Scan(&s.ID, &s.UserID, &s.Title, &s.Description, &s.Price, &s.CreatedAt); err != nil {
+			return nil, err
+		}
+		list = append(list, s)
+	}
+	return list, rows.Err()
+}
+
+func CreateShopReward(ctx context.Context, db *pgxpool.Pool, s *ShopReward) error {
+	_, err := db.Exec(ctx, `INSERT INTO shop_rewards(id, user_id, title, description, price, created_at)
+	VALUES($1,$2,$3,$4,$5,$6)`,
+		s.ID, s.UserID, s.Title, s.Description, s.Price, s.CreatedAt)
+	return err
+}
+
+func GetShopRewardByID(ctx context.Context, db *pgxpool.Pool, id uuid.UUID) (*ShopReward, error) {
+	var s ShopReward
+	if err := db.QueryRow(ctx, `SELECT id, user_id, title, description, price, created_at
+	FROM shop_rewards WHERE id=$1`, id).Scan(&s.ID, &s.UserID, &s.Title, &s.Description, &s.Price, &s.CreatedAt); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func UpdateShopReward(ctx context.Context, db *pgxpool.Pool, s *ShopReward) error {
+	_, err := db.Exec(ctx, `UPDATE shop_rewards SET title=$2, description=$3, price=$4 WHERE id=$1`,
+		s.ID, s.Title, s.Description, s.Price)
+	return err
+}
+
+func DeleteShopReward(ctx context.Context, db *pgxpool.Pool, id uuid.UUID) error {
+	_, err := db.Exec(ctx, `DELETE FROM shop_rewards WHERE id=$1`, id)
+	return err
+}
+
+// RedeemShopReward compra a recompensa com gold. O preço é lido dentro da transação, com a linha do
+// usuário travada, então nem uma edição de preço nem dois resgates simultâneos deixam o gold negativo.
+// pgx.ErrNoRows se a recompensa não existir ou não for do usuário; ErrInsufficientGold se faltar gold.
+func RedeemShopReward(ctx context.Context, db *pgxpool.Pool, userID, rewardID uuid.UUID) (*RewardRedemption, int64, error) {
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	var gold int64
+	if err := tx.QueryRow(ctx, `SELECT gold FROM users WHERE id=$1 FOR UPDATE`, userID).Scan(&gold); err != nil {
+		return nil, 0, err
+	}
+	rd := RewardRedemption{ID: uuid.New(), UserID: userID, RewardID: &rewardID, CreatedAt: time.Now()}
+	if err := tx.QueryRow(ctx, `SELECT title, price FROM shop_rewards WHERE id=$1 AND user_id=$2`,
+		rewardID, userID).Scan(&rd.Title, &rd.Price); err != nil {
+		return nil, 0, err
+	}
+	if gold < rd.Price {
+		return nil, 0, ErrInsufficientGold
+	}
+	if err := postTx(ctx, tx, userID, Posting{Reason: LedgerShopRedemption, RefID: &rd.ID, Gold: -rd.Price}); err != nil {
+		return nil, 0, err
+	}
+	gold -= rd.Price
+	if _, err := tx.Exec(ctx, `INSERT INTO reward_redemptions(id, user_id, reward_id, title, price, created_at)
+	VALUES($1,$2,$3,$4,$5,$6)`,
+		rd.ID, rd.UserID, rd.RewardID, rd.Title, rd.Price, rd.CreatedAt); err != nil {
+		return nil, 0, err
+	}
+
+	return &rd, gold, tx.Commit(ctx)
+}
+
+func ListRewardRedemptions(ctx context.Context, db *pgxpool.Pool, userID uuid.UUID) ([]RewardRedemption, error) {
+	rows, err := db.Query(ctx, `SELECT id, user_id, reward_id, title, price, created_at
+	FROM reward_redemptions WHERE user_id=$1 ORDER BY created_at DESC`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []RewardRedemption{}
+	for rows.Next() {
+		var rd RewardRedemption
+		if err := rows.Scan(&rd.ID, &rd.UserID, &rd.RewardID, &rd.Title, &rd.Price, &rd.CreatedAt); err != nil {
+			return nil, err
+		}
+		list = append(list, rd)
+	}
+	return list, rows.Err()
+}
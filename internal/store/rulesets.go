@@ -0,0 +1,27 @@
+package store
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var ErrRulesetConflict = errors.New("ruleset version already registered with different content")
+
+// SaveRuleset registra o conteúdo (JSON) de uma versão do ruleset. Versão já registrada só passa se o
+// conteúdo for o mesmo (comparado como JSONB, então espaço e ordem das chaves não contam).
+func SaveRuleset(ctx context.Context, db *pgxpool.Pool, version string, body []byte) error {
+	if _, err := db.Exec(ctx, `INSERT INTO rulesets(version, body) VALUES($1, $2) ON CONFLICT (version) DO NOTHING`,
+		version, body); err != nil {
+		return err
+	}
+	var same bool
+	if err := db.QueryRow(ctx, `SELECT body = $2::jsonb FROM rulesets WHERE version=$1`, version, body).Scan(&same); err != nil {
+		return err
+	}
+	if !same {
+		return ErrRulesetConflict
+	}
+	return nil
+}
@@ -2,39 +2,214 @@ package store
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+var (
+	ErrNoRankKey = errors.New("no active rank key")
+	ErrGateOpen  = errors.New("another gate is already open")
+)
+
+const insertFocusRun = `INSERT INTO focus_runs(id, user_id, quest_id, dungeon_rank, kind, start_at, target_minutes, xp_earned, gold_earned, ruleset_version, loot_seed)
+	VALUES($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11)`
+
+// CreateFocusRun abre o gate. ErrGateOpen se o hunter já tem outro aberto.
 func CreateFocusRun(ctx context.Context, db *pgxpool.Pool, r *FocusRun) error {
-	_, err := db.Exec(ctx, `INSERT INTO focus_runs(id, user_id, quest_id, dungeon_rank, start_at, target_minutes, xp_earned, gold_earned)
-	VALUES($1,$2,$3,$4,$5,$6,$7,$8)`,
-		r.ID, r.UserID, r.QuestID, r.DungeonRank, r.StartAt, r.TargetMinutes, r.XPEarned, r.GoldEarned)
+	_, err := db.Exec(ctx, insertFocusRun,
+		r.ID, r.UserID, r.QuestID, r.DungeonRank, r.Kind, r.StartAt, r.TargetMinutes, r.XPEarned, r.GoldEarned, r.RulesetVersion, r.LootSeed)
+	return openRunErr(err)
+}
+
+// openRunErr troca a violação de idx_focus_runs_open_user (um gate aberto por hunter) por ErrGateOpen.
+func openRunErr(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" && pgErr.ConstraintName == "idx_focus_runs_open_user" {
+		return ErrGateOpen
+	}
 	return err
 }
 
+// CreateFocusRunWithRankKey abre um gate tiers ranks acima do que o hunter pode, gastando a chave de
+// rank ativa mais antiga que alcance isso. ErrNoRankKey se não houver nenhuma, ErrGateOpen se já
+// houver outro gate aberto (aí a chave não é gasta).
+func CreateFocusRunWithRankKey(ctx context.Context, db *pgxpool.Pool, r *FocusRun, tiers int) error {
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	keys, err := activeEffectsTx(ctx, tx, r.UserID, EffectRankKey)
+	if err != nil {
+		return err
+	}
+	for _, k := range keys {
+		if int(k.Power) < tiers {
+			continue
+		}
+		if _, err := tx.Exec(ctx, insertFocusRun,
+			r.ID, r.UserID, r.QuestID, r.DungeonRank, r.Kind, r.StartAt, r.TargetMinutes, r.XPEarned, r.GoldEarned, r.RulesetVersion, r.LootSeed); err != nil {
+			return openRunErr(err)
+		}
+		if err := consumeEffectTx(ctx, tx, k.ID, &r.ID); err != nil {
+			return err
+		}
+		return tx.Commit(ctx)
+	}
+	return ErrNoRankKey
+}
+
 func GetFocusRunByID(ctx context.Context, db *pgxpool.Pool, id uuid.UUID) (FocusRun, error) {
-	row := db.QueryRow(ctx, `SELECT id, user_id, quest_id, dungeon_rank, start_at, end_at, target_minutes, result, xp_earned, gold_earned
+	row := db.QueryRow(ctx, `SELECT id, user_id, quest_id, dungeon_rank, kind, start_at, end_at, target_minutes, result, xp_earned, gold_earned, ruleset_version, loot_seed, loot
 	FROM focus_runs WHERE id=$1`, id)
 
 	var r FocusRun
-	if err := row.Scan(&r.ID, &r.UserID, &r.QuestID, &r.DungeonRank, &r.StartAt, &r.EndAt,
-		&r.TargetMinutes, &r.Result, &r.XPEarned, &r.GoldEarned); err != nil {
+	if err := row.Scan(&r.ID, &r.UserID, &r.QuestID, &r.DungeonRank, &r.Kind, &r.StartAt, &r.EndAt,
+		&r.TargetMinutes, &r.Result, &r.XPEarned, &r.GoldEarned, &r.RulesetVersion, &r.LootSeed, &r.Loot); err != nil {
+		return FocusRun{}, err
+	}
+	rows, err := db.Query(ctx, `SELECT id, run_id, started_at, ended_at FROM focus_run_pauses WHERE run_id=$1 ORDER BY started_at`, id)
+	if err != nil {
+		return FocusRun{}, err
+	}
+	if r.Pauses, err = scanPauses(rows); err != nil {
 		return FocusRun{}, err
 	}
 	return r, nil
 }
 
+// FinishFocusRun fecha o gate e, se ele estava pausado, a pausa aberta junto. ErrRunClosed se alguém
+// (outro request, o worker de expiração) fechou antes.
 func FinishFocusRun(ctx context.Context, db *pgxpool.Pool, r *FocusRun) error {
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := finishFocusRunTx(ctx, tx, r); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func finishFocusRunTx(ctx context.Context, tx pgx.Tx, r *FocusRun) error {
 	now := time.Now()
 	if r.EndAt == nil {
 		r.EndAt = &now
 	}
-	_, err := db.Exec(ctx, `UPDATE focus_runs
-	SET end_at=$2, result=$3, xp_earned=$4, gold_earned=$5
-	WHERE id=$1`,
-		r.ID, r.EndAt, r.Result, r.XPEarned, r.GoldEarned)
+	tag, err := tx.Exec(ctx, `UPDATE focus_runs
+	SET end_at=$2, result=$3, xp_earned=$4, gold_earned=$5, ruleset_version=$6, loot=$7
+	WHERE id=$1 AND end_at IS NULL`,
+		r.ID, r.EndAt, r.Result, r.XPEarned, r.GoldEarned, r.RulesetVersion, r.Loot)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrRunClosed
+	}
+	_, err = tx.Exec(ctx, `UPDATE focus_run_pauses SET ended_at=$2 WHERE run_id=$1 AND ended_at IS NULL`,
+		r.ID, r.EndAt)
 	return err
 }
+
+func ListFocusRunsByUser(ctx context.Context, db *pgxpool.Pool, userID uuid.UUID) ([]FocusRun, error) {
+	rows, err := db.Query(ctx, `SELECT id, user_id, quest_id, dungeon_rank, kind, start_at, end_at, target_minutes, result, xp_earned, gold_earned, ruleset_version, loot_seed, loot
+	FROM focus_runs WHERE user_id=$1 ORDER BY start_at DESC`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []FocusRun{}
+	for rows.Next() {
+		var r FocusRun
+		if err := rows.Scan(&r.ID, &r.UserID, &r.QuestID, &r.DungeonRank, &r.Kind, &r.StartAt, &r.EndAt,
+			&r.TargetMinutes, &r.Result, &r.XPEarned, &r.GoldEarned, &r.RulesetVersion, &r.LootSeed, &r.Loot); err != nil {
+			return nil, err
+		}
+		r.Pauses = []FocusRunPause{}
+		list = append(list, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	// pausas de todos os gates numa query só, distribuídas pelo run_id
+	idx := make(map[uuid.UUID]int, len(list))
+	for i, r := range list {
+		idx[r.ID] = i
+	}
+	prows, err := db.Query(ctx, `SELECT p.id, p.run_id, p.started_at, p.ended_at
+	FROM focus_run_pauses p JOIN focus_runs f ON f.id = p.run_id
+	WHERE f.user_id=$1 ORDER BY p.started_at`, userID)
+	if err != nil {
+		return nil, err
+	}
+	pauses, err := scanPauses(prows)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range pauses {
+		if i, ok := idx[p.RunID]; ok {
+			list[i].Pauses = append(list[i].Pauses, p)
+		}
+	}
+	return list, nil
+}
+
+// CountClears conta os gates fechados com sucesso num rank de dungeon (requisito da reavaliação).
+func CountClears(ctx context.Context, db *pgxpool.Pool, userID uuid.UUID, dungeonRank string) (int, error) {
+	var n int
+	err := db.QueryRow(ctx, `SELECT count(*) FROM focus_runs WHERE user_id=$1 AND dungeon_rank=$2 AND result=$3`,
+		userID, dungeonRank, RunResultSuccess).Scan(&n)
+	return n, err
+}
+
+// ExpireOverdueRuns fecha como expired, sem recompensa, até limit gates ainda abertos cujo alvo
+// (start_at + target_minutes) terminou antes de cutoff. Cada um passa pelo AbandonFocusRun, então o
+// streak quebra na mesma transação que fecha o gate; um gate que um Close (ou outra réplica) fechou
+// no meio do caminho é só pulado. Devolve os gates expirados.
+func ExpireOverdueRuns(ctx context.Context, db *pgxpool.Pool, cutoff time.Time, limit int) ([]FocusRun, error) {
+	rows, err := db.Query(ctx, `SELECT id, user_id, ruleset_version FROM focus_runs
+	WHERE end_at IS NULL AND start_at + make_interval(mins => target_minutes) < $1
+	ORDER BY start_at LIMIT $2`, cutoff, limit)
+	if err != nil {
+		return nil, err
+	}
+	var due []FocusRun
+	for rows.Next() {
+		var r FocusRun
+		if err := rows.Scan(&r.ID, &r.UserID, &r.RulesetVersion); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		due = append(due, r)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	list := []FocusRun{}
+	result := RunResultExpired
+	for _, r := range due {
+		r.Result = &result
+		err := AbandonFocusRun(ctx, db, &r)
+		if errors.Is(err, ErrRunClosed) {
+			continue
+		}
+		if err != nil {
+			return list, err
+		}
+		list = append(list, r)
+	}
+	return list, nil
+}
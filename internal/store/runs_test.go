@@ -0,0 +1,112 @@
+package store
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// testDB abre o Postgres de TEST_DATABASE_URL com as migrations aplicadas. Sem a variável o
+// teste é pulado, então go test ./... continua rodando sem banco.
+func testDB(t *testing.T) *pgxpool.Pool {
+	t.Helper()
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+	pool, err := pgxpool.New(context.Background(), dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(pool.Close)
+	if err := RunMigrations(pool); err != nil {
+		t.Fatal(err)
+	}
+	return pool
+}
+
+// overdueRun cria um hunter com streak e um gate aberto que já passou do alvo há horas.
+func overdueRun(t *testing.T, db *pgxpool.Pool, streak int) FocusRun {
+	t.Helper()
+	ctx := context.Background()
+	u := &User{ID: uuid.New(), Email: uuid.NewString() + "@example.com", Level: 1, Stats: map[string]int{},
+		Streak: streak, Role: RoleUser, HunterRank: "E", CreatedAt: time.Now()}
+	if err := CreateUser(ctx, db, u); err != nil {
+		t.Fatal(err)
+	}
+	r := FocusRun{ID: uuid.New(), UserID: u.ID, DungeonRank: "E", Kind: RunKindNormal,
+		StartAt: time.Now().Add(-3 * time.Hour), TargetMinutes: 25}
+	if err := CreateFocusRun(ctx, db, &r); err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func expire(t *testing.T, db *pgxpool.Pool, runID uuid.UUID) {
+	t.Helper()
+	runs, err := ExpireOverdueRuns(context.Background(), db, time.Now(), 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range runs {
+		if r.ID == runID {
+			return
+		}
+	}
+	t.Fatalf("run %s was not expired", runID)
+}
+
+func TestExpireOverdueRunsBreaksStreak(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+	run := overdueRun(t, db, 5)
+
+	expire(t, db, run.ID)
+
+	u, err := GetUserByID(ctx, db, run.UserID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.Streak != 0 {
+		t.Errorf("streak = %d, want 0", u.Streak)
+	}
+	got, err := GetFocusRunByID(ctx, db, run.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.EndAt == nil || got.Result == nil || *got.Result != RunResultExpired || got.XPEarned != 0 {
+		t.Errorf("run = %+v, want closed as expired without XP", got)
+	}
+}
+
+func TestExpireOverdueRunsSpendsShield(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+	run := overdueRun(t, db, 5)
+	shieldID := uuid.New()
+	if _, err := db.Exec(ctx, `INSERT INTO user_effects(id, user_id, item_id, effect, power) VALUES($1,$2,'streak_shield',$3,1)`,
+		shieldID, run.UserID, EffectStreakShield); err != nil {
+		t.Fatal(err)
+	}
+
+	expire(t, db, run.ID)
+
+	u, err := GetUserByID(ctx, db, run.UserID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.Streak != 5 {
+		t.Errorf("streak = %d, want 5 (shielded)", u.Streak)
+	}
+	var consumedRun *uuid.UUID
+	if err := db.QueryRow(ctx, `SELECT consumed_run_id FROM user_effects WHERE id=$1`, shieldID).Scan(&consumedRun); err != nil {
+		t.Fatal(err)
+	}
+	if consumedRun == nil || *consumedRun != run.ID {
+		t.Errorf("shield consumed by %v, want run %s", consumedRun, run.ID)
+	}
+}
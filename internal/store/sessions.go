@@ -0,0 +1,93 @@
+package store
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// CreateSession grava a sessão e o primeiro refresh token da família dela na mesma transação.
+func CreateSession(ctx context.Context, db *pgxpool.Pool, s *Session, rt *RefreshToken) error {
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `INSERT INTO sessions(id, user_id, user_agent, ip, current_jti, created_at, last_seen_at)
+	VALUES($1,$2,$3,$4,$5,$6,$7)`,
+		s.ID, s.UserID, s.UserAgent, s.IP, s.CurrentJTI, s.CreatedAt, s.LastSeenAt); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, `INSERT INTO refresh_tokens(id, user_id, family_id, token_hash, expires_at, created_at)
+	VALUES($1,$2,$3,$4,$5,$6)`,
+		rt.ID, rt.UserID, rt.FamilyID, rt.TokenHash, rt.ExpiresAt, rt.CreatedAt); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func ListActiveSessions(ctx context.Context, db *pgxpool.Pool, userID uuid.UUID) ([]Session, error) {
+	rows, err := db.Query(ctx, `SELECT id, user_id, user_agent, ip, created_at, last_seen_at
+	FROM sessions WHERE user_id=$1 AND revoked_at IS NULL ORDER BY last_seen_at DESC`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []Session{}
+	for rows.Next() {
+		var s Session
+		if err := rows.Scan(&s.ID, &s.UserID, &s.UserAgent, &s.IP, &s.CreatedAt, &s.LastSeenAt); err != nil {
+			return nil, err
+		}
+		list = append(list, s)
+	}
+	return list, rows.Err()
+}
+
+// RevokeSession derruba a sessão e a família de refresh tokens dela. false se não existe ou não é do usuário.
+func RevokeSession(ctx context.Context, db *pgxpool.Pool, userID, sessionID uuid.UUID) (bool, error) {
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, `UPDATE sessions SET revoked_at=now() WHERE id=$1 AND user_id=$2 AND revoked_at IS NULL`,
+		sessionID, userID)
+	if err != nil {
+		return false, err
+	}
+	if tag.RowsAffected() == 0 {
+		return false, nil
+	}
+	if _, err := tx.Exec(ctx, `UPDATE refresh_tokens SET revoked_at=now() WHERE family_id=$1 AND revoked_at IS NULL`,
+		sessionID); err != nil {
+		return false, err
+	}
+
+	return true, tx.Commit(ctx)
+}
+
+// ListSessions devolve todas as sessões, inclusive as revogadas (usado no export).
+func ListSessions(ctx context.Context, db *pgxpool.Pool, userID uuid.UUID) ([]Session, error) {
+	rows, err := db.Query(ctx, `SELECT id, user_id, user_agent, ip, created_at, last_seen_at, revoked_at
+	FROM sessions WHERE user_id=$1 ORDER BY created_at DESC`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []Session{}
+	for rows.Next() {
+		var s Session
+		if err := rows.Scan(&s.ID, &s.UserID, &s.UserAgent, &s.IP, &s.CreatedAt, &s.LastSeenAt, &s.RevokedAt); err != nil {
+			return nil, err
+		}
+		list = append(list, s)
+	}
+	return list, rows.Err()
+}
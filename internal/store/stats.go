@@ -0,0 +1,85 @@
+package store
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	ErrNotEnoughStatPoints = errors.New("not enough stat points")
+	ErrInsufficientGold    = errors.New("insufficient gold")
+	ErrNothingToRespec     = errors.New("no allocated stat points to reset")
+)
+
+// AllocateStatPoints soma alloc aos stats gastando os pontos livres. alloc já vem validado
+// (stats conhecidos, valores >= 0); total é a soma dele.
+func AllocateStatPoints(ctx context.Context, db *pgxpool.Pool, userID uuid.UUID, alloc map[string]int, total int) (*User, error) {
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	var stats map[string]int
+	var points int
+	if err := tx.QueryRow(ctx, `SELECT stats, stat_points FROM users WHERE id=$1 FOR UPDATE`, userID).Scan(&stats, &points); err != nil {
+		return nil, err
+	}
+	if total > points {
+		return nil, ErrNotEnoughStatPoints
+	}
+	for name, pts := range alloc {
+		if pts > points {
+			return nil, ErrNotEnoughStatPoints
+		}
+		stats[name] += pts
+	}
+	if _, err := tx.Exec(ctx, `UPDATE users SET stats=$2, stat_points = stat_points - $3 WHERE id=$1`,
+		userID, stats, total); err != nil {
+		return nil, err
+	}
+	u, err := scanUser(tx.QueryRow(ctx, `SELECT `+userColumns+` FROM users WHERE id=$1`, userID))
+	if err != nil {
+		return nil, err
+	}
+
+	return u, tx.Commit(ctx)
+}
+
+// RespecStats volta os stats pra base, devolve os pontos gastos (refund) e cobra cost em gold.
+func RespecStats(ctx context.Context, db *pgxpool.Pool, userID uuid.UUID, base map[string]int, refund func(map[string]int) int, cost int64) (*User, error) {
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	var stats map[string]int
+	var gold int64
+	if err := tx.QueryRow(ctx, `SELECT stats, gold FROM users WHERE id=$1 FOR UPDATE`, userID).Scan(&stats, &gold); err != nil {
+		return nil, err
+	}
+	points := refund(stats)
+	if points == 0 {
+		return nil, ErrNothingToRespec
+	}
+	if gold < cost {
+		return nil, ErrInsufficientGold
+	}
+	if _, err := tx.Exec(ctx, `UPDATE users SET stats=$2, stat_points = stat_points + $3 WHERE id=$1`,
+		userID, base, points); err != nil {
+		return nil, err
+	}
+	if err := postTx(ctx, tx, userID, Posting{Reason: LedgerStatRespec, Gold: -cost}); err != nil {
+		return nil, err
+	}
+	u, err := scanUser(tx.QueryRow(ctx, `SELECT `+userColumns+` FROM users WHERE id=$1`, userID))
+	if err != nil {
+		return nil, err
+	}
+
+	return u, tx.Commit(ctx)
+}
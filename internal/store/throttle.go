@@ -0,0 +1,56 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// LoginBlock é o bloqueio mais longo ativo entre as chaves consultadas.
+type LoginBlock struct {
+	Key         string
+	LockedUntil time.Time
+	Lockout     bool
+}
+
+// GetLoginBlock devolve nil se nenhuma das chaves ("account:<email>", "ip:<addr>") estiver bloqueada agora.
+func GetLoginBlock(ctx context.Context, db *pgxpool.Pool, keys ...string) (*LoginBlock, error) {
+	rows, err := db.Query(ctx, `SELECT key, locked_until, lockout FROM login_throttles
+	WHERE key = ANY($1) AND locked_until > now() ORDER BY locked_until DESC LIMIT 1`, keys)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		return nil, rows.Err()
+	}
+	var b LoginBlock
+	if err := rows.Scan(&b.Key, &b.LockedUntil, &b.Lockout); err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+// RecordLoginFailure conta mais uma falha pra chave. Falhas mais antigas que window não contam mais.
+func RecordLoginFailure(ctx context.Context, db *pgxpool.Pool, key string, window time.Duration) (int, error) {
+	var failures int
+	err := db.QueryRow(ctx, `INSERT INTO login_throttles(key, failures, last_failure_at) VALUES($1, 1, now())
+	ON CONFLICT (key) DO UPDATE SET
+		failures = CASE WHEN login_throttles.last_failure_at < now() - make_interval(secs => $2)
+			THEN 1 ELSE login_throttles.failures + 1 END,
+		last_failure_at = now()
+	RETURNING failures`, key, window.Seconds()).Scan(&failures)
+	return failures, err
+}
+
+func BlockLogin(ctx context.Context, db *pgxpool.Pool, key string, until time.Time, lockout bool) error {
+	_, err := db.Exec(ctx, `UPDATE login_throttles SET locked_until=$2, lockout=$3 WHERE key=$1`, key, until, lockout)
+	return err
+}
+
+func ClearLoginFailures(ctx context.Context, db *pgxpool.Pool, key string) error {
+	_, err := db.Exec(ctx, `DELETE FROM login_throttles WHERE key=$1`, key)
+	return err
+}
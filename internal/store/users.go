@@ -5,54 +5,116 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 func CreateUser(ctx context.Context, db *pgxpool.Pool, u *User) error {
-	_, err := db.Exec(ctx, `INSERT INTO users(id, email, pass_hash, level, xp, gold, stats, streak, last_active_date, created_at)
-	VALUES($1,$2,$3,$4,$5,$6,$7,$8,$9,$10)`,
-		u.ID, u.Email, u.PassHash, u.Level, u.XP, u.Gold, u.Stats, u.Streak, u.LastActiveDate, u.CreatedAt)
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := createUserTx(ctx, tx, u); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// createUserTx é o único INSERT de usuário: cadastro por senha (CreateUser) e via OIDC
+// (CreateUserWithIdentity) passam por aqui.
+func createUserTx(ctx context.Context, tx pgx.Tx, u *User) error {
+	_, err := tx.Exec(ctx, `INSERT INTO users(id, email, pass_hash, level, xp, gold, stats, streak, last_active_date, email_verified_at, role, hunter_rank, created_at)
+	VALUES($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13)`,
+		u.ID, u.Email, u.PassHash, u.Level, u.XP, u.Gold, u.Stats, u.Streak, u.LastActiveDate, u.EmailVerifiedAt, u.Role, u.HunterRank, u.CreatedAt)
 	return err
 }
 
-func GetUserByEmail(ctx context.Context, db *pgxpool.Pool, email string) (*User, error) {
-	row := db.QueryRow(ctx, `SELECT id, email, pass_hash, level, xp, gold, stats, streak, last_active_date, created_at FROM users WHERE email=$1`, email)
+// userColumns é a ordem que scanUser espera.
+const userColumns = `id, email, pass_hash, level, hunter_rank, xp, gold, stats, stat_points, streak, last_active_date, email_verified_at, delete_after,
+	role, banned_at, ban_reason, password_reset_required, created_at`
+
+func scanUser(row pgx.Row) (*User, error) {
 	var u User
-	if err := row.Scan(&u.ID, &u.Email, &u.PassHash, &u.Level, &u.XP, &u.Gold, &u.Stats, &u.Streak, &u.LastActiveDate, &u.CreatedAt); err != nil {
+	if err := row.Scan(&u.ID, &u.Email, &u.PassHash, &u.Level, &u.HunterRank, &u.XP, &u.Gold, &u.Stats, &u.StatPoints, &u.Streak,
+		&u.LastActiveDate, &u.EmailVerifiedAt, &u.DeleteAfter, &u.Role, &u.BannedAt, &u.BanReason, &u.PasswordResetRequired, &u.CreatedAt); err != nil {
 		return nil, err
 	}
 	return &u, nil
 }
 
+func GetUserByEmail(ctx context.Context, db *pgxpool.Pool, email string) (*User, error) {
+	return scanUser(db.QueryRow(ctx, `SELECT `+userColumns+` FROM users WHERE email=$1`, email))
+}
+
 func GetUserByID(ctx context.Context, db *pgxpool.Pool, id uuid.UUID) (*User, error) {
-	row := db.QueryRow(ctx, `SELECT id, email, pass_hash, level, xp, gold, stats, streak, last_active_date, created_at FROM users WHERE id=$1`, id)
-	var u User
-	if err := row.Scan(&u.ID, &u.Email, &u.PassHash, &u.Level, &u.XP, &u.Gold, &u.Stats, &u.Streak, &u.LastActiveDate, &u.CreatedAt); err != nil {
-		return nil, err
+	return scanUser(db.QueryRow(ctx, `SELECT `+userColumns+` FROM users WHERE id=$1`, id))
+}
+
+// MarkEmailVerified só marca se o e-mail ainda for o mesmo do link de verificação.
+func MarkEmailVerified(ctx context.Context, db *pgxpool.Pool, id uuid.UUID, email string) (bool, error) {
+	tag, err := db.Exec(ctx, `UPDATE users SET email_verified_at=COALESCE(email_verified_at, now()) WHERE id=$1 AND email=$2`, id, email)
+	if err != nil {
+		return false, err
 	}
-	return &u, nil
+	return tag.RowsAffected() > 0, nil
+}
+
+func IsEmailVerified(ctx context.Context, db *pgxpool.Pool, id uuid.UUID) (bool, error) {
+	var ok bool
+	if err := db.QueryRow(ctx, `SELECT email_verified_at IS NOT NULL FROM users WHERE id=$1`, id).Scan(&ok); err != nil {
+		return false, err
+	}
+	return ok, nil
+}
+
+// GateReward é o que um gate fechado credita. Run é o gate, fechado na mesma transação com xp_earned e
+// gold_earned finais (ErrRunClosed se já estava fechado). Drops são ids do catálogo de itens. Boost, se
+// não for nil, recebe a poção de XP ativa (só em gate com sucesso) e devolve xp e gold já com ela; a
+// poção é consumida.
+type GateReward struct {
+	Run     *FocusRun
+	XP      int64
+	Gold    int64
+	Success bool
+	Drops   []string
+	Boost   func(e UserEffect, xp, gold int64) (int64, int64)
+	// RankUp é a promoção de um gate de reavaliação vencido (nil nos outros gates).
+	RankUp *RankUp
+}
+
+// RankUp sobe o hunter de From pra To junto com a recompensa. Promoted volta false se ele já não
+// estava mais em From (outra reavaliação fechou antes).
+type RankUp struct {
+	From     string
+	To       string
+	Promoted bool
 }
 
-// AddXPAndGold atualiza xp, gold e streak respeitando o dia em America/Sao_Paulo.
-// success=true significa que o usuário concluiu o gate com sucesso.
-func AddXPAndGold(ctx context.Context, db *pgxpool.Pool, userID uuid.UUID, xp, gold int64, success bool) error {
+// AddXPAndGold atualiza xp, gold e streak respeitando o dia em America/Sao_Paulo e, na mesma transação,
+// fecha o gate, aplica os efeitos ativos, guarda os drops, sobe o nível pela curva e, se rw.RankUp vier, o rank
+// do hunter. Success=true significa que o
+// usuário concluiu o gate com sucesso. Devolve o evento de level up (nil se o nível não mudou).
+func AddXPAndGold(ctx context.Context, db *pgxpool.Pool, userID uuid.UUID, rw GateReward, curve LevelCurve) (*LevelUpEvent, error) {
 	loc, _ := time.LoadLocation("America/Sao_Paulo")
 	today := time.Now().In(loc).Truncate(24 * time.Hour)
+	runID := &rw.Run.ID
 
 	tx, err := db.Begin(ctx)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer tx.Rollback(ctx)
 
 	var oldStreak int
 	var lastDate *time.Time
 	if err := tx.QueryRow(ctx, `SELECT streak, last_active_date FROM users WHERE id=$1 FOR UPDATE`, userID).Scan(&oldStreak, &lastDate); err != nil {
-		return err
+		return nil, err
 	}
 
 	newStreak := oldStreak
-	if success {
+	if rw.Success {
 		if lastDate == nil {
 			newStreak = 1
 		} else {
@@ -66,34 +128,113 @@ func AddXPAndGold(ctx context.Context, db *pgxpool.Pool, userID uuid.UUID, xp, g
 				// dia seguinte, +1
 				newStreak = oldStreak + 1
 			default:
-				// passou mais de 1 dia, reseta pra 1
+				// passou mais de 1 dia, reseta pra 1 (ou segue, se tiver escudo)
 				newStreak = 1
+				if oldStreak > 0 {
+					shielded, err := shieldStreakTx(ctx, tx, userID, runID)
+					if err != nil {
+						return nil, err
+					}
+					if shielded {
+						newStreak = oldStreak + 1
+					}
+				}
 			}
 		}
 	} else {
-		// abandon => quebra streak
+		// abandon => quebra streak, a não ser que um escudo segure
 		newStreak = 0
+		if oldStreak > 0 {
+			shielded, err := shieldStreakTx(ctx, tx, userID, runID)
+			if err != nil {
+				return nil, err
+			}
+			if shielded {
+				newStreak = oldStreak
+			}
+		}
 	}
 
-	if _, err := tx.Exec(ctx, `UPDATE users SET xp = xp + $2, gold = gold + $3, streak=$4, last_active_date=$5 WHERE id=$1`,
-		userID, xp, gold, newStreak, today); err != nil {
+	xp, gold := rw.XP, rw.Gold
+	if rw.Success && rw.Boost != nil {
+		potions, err := activeEffectsTx(ctx, tx, userID, EffectXPMultiplier)
+		if err != nil {
+			return nil, err
+		}
+		if len(potions) > 0 {
+			xp, gold = rw.Boost(potions[0], xp, gold)
+			if err := consumeEffectTx(ctx, tx, potions[0].ID, runID); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	rw.Run.XPEarned, rw.Run.GoldEarned = xp, gold
+	if err := finishFocusRunTx(ctx, tx, rw.Run); err != nil {
+		return nil, err
+	}
+	if err := postTx(ctx, tx, userID, Posting{Reason: LedgerGateReward, RefID: runID, XP: xp, Gold: gold}); err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec(ctx, `UPDATE users SET streak=$2, last_active_date=$3 WHERE id=$1`,
+		userID, newStreak, today); err != nil {
+		return nil, err
+	}
+	if err := grantItemsTx(ctx, tx, userID, rw.Drops); err != nil {
+		return nil, err
+	}
+	levelUp, err := applyLevelTx(ctx, tx, userID, curve, runID)
+	if err != nil {
+		return nil, err
+	}
+	if rw.RankUp != nil {
+		if rw.RankUp.Promoted, err = promoteHunterRankTx(ctx, tx, userID, rw.RankUp.From, rw.RankUp.To); err != nil {
+			return nil, err
+		}
+	}
+
+	return levelUp, tx.Commit(ctx)
+}
+
+// AbandonFocusRun fecha o gate sem recompensa (abandono sem crédito ou expiração) e, na mesma
+// transação, quebra o streak; um escudo ativo segura e fica ligado ao gate. ErrRunClosed se alguém
+// fechou antes, e aí nada muda.
+func AbandonFocusRun(ctx context.Context, db *pgxpool.Pool, r *FocusRun) error {
+	tx, err := db.Begin(ctx)
+	if err != nil {
 		return err
 	}
+	defer tx.Rollback(ctx)
 
+	// trava o usuário antes do gate, na mesma ordem do AddXPAndGold
+	if err := updateStreakTx(ctx, tx, r.UserID, false, &r.ID); err != nil {
+		return err
+	}
+	if err := finishFocusRunTx(ctx, tx, r); err != nil {
+		return err
+	}
 	return tx.Commit(ctx)
 }
 
 // UpdateStreak usado pra abandonar gate explícito
 func UpdateStreak(ctx context.Context, db *pgxpool.Pool, userID uuid.UUID, success bool) error {
-	loc, _ := time.LoadLocation("America/Sao_Paulo")
-	today := time.Now().In(loc).Truncate(24 * time.Hour)
-
 	tx, err := db.Begin(ctx)
 	if err != nil {
 		return err
 	}
 	defer tx.Rollback(ctx)
 
+	if err := updateStreakTx(ctx, tx, userID, success, nil); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// updateStreakTx é o UpdateStreak dentro de uma transação; runID liga o escudo gasto ao gate.
+func updateStreakTx(ctx context.Context, tx pgx.Tx, userID uuid.UUID, success bool, runID *uuid.UUID) error {
+	loc, _ := time.LoadLocation("America/Sao_Paulo")
+	today := time.Now().In(loc).Truncate(24 * time.Hour)
+
 	var oldStreak int
 	if err := tx.QueryRow(ctx, `SELECT streak FROM users WHERE id=$1 FOR UPDATE`, userID).Scan(&oldStreak); err != nil {
 		return err
@@ -102,14 +243,35 @@ func UpdateStreak(ctx context.Context, db *pgxpool.Pool, userID uuid.UUID, succe
 	newStreak := oldStreak
 	if success {
 		newStreak = oldStreak + 1
-	} else {
+	} else if oldStreak > 0 {
 		newStreak = 0
+		shielded, err := shieldStreakTx(ctx, tx, userID, runID)
+		if err != nil {
+			return err
+		}
+		if shielded {
+			newStreak = oldStreak
+		}
 	}
+	_, err := tx.Exec(ctx, `UPDATE users SET streak=$2, last_active_date=$3 WHERE id=$1`,
+		userID, newStreak, today)
+	return err
+}
 
-	if _, err := tx.Exec(ctx, `UPDATE users SET streak=$2, last_active_date=$3 WHERE id=$1`,
-		userID, newStreak, today); err != nil {
-		return err
+// shieldStreakTx gasta o escudo de streak ativo mais antigo, se houver; true = o streak foi protegido.
+func shieldStreakTx(ctx context.Context, tx pgx.Tx, userID uuid.UUID, runID *uuid.UUID) (bool, error) {
+	shields, err := activeEffectsTx(ctx, tx, userID, EffectStreakShield)
+	if err != nil || len(shields) == 0 {
+		return false, err
 	}
+	return true, consumeEffectTx(ctx, tx, shields[0].ID, runID)
+}
 
-	return tx.Commit(ctx)
+// promoteHunterRankTx sobe o hunter de from pra to; false se ele já não estava mais em from.
+func promoteHunterRankTx(ctx context.Context, tx pgx.Tx, userID uuid.UUID, from, to string) (bool, error) {
+	tag, err := tx.Exec(ctx, `UPDATE users SET hunter_rank=$3 WHERE id=$1 AND hunter_rank=$2`, userID, from, to)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
 }
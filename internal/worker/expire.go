@@ -0,0 +1,44 @@
+package worker
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/gabrieldemesio/solo-leveling-go-mvp-v2/internal/core/battle"
+	"github.com/gabrieldemesio/solo-leveling-go-mvp-v2/internal/core/ruleset"
+	"github.com/gabrieldemesio/solo-leveling-go-mvp-v2/internal/store"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const expireBatch = 100
+
+// ExpireGates fecha como expired os gates que ficaram abertos (cliente caiu, app fechado) mais de
+// after depois do alvo. A maior pausa permitida pelo ruleset entra na conta, pra nunca expirar um
+// gate pausado dentro do limite. Expirar quebra o streak como um abandono, na mesma transação.
+func ExpireGates(db *pgxpool.Pool, rules *ruleset.Holder, after time.Duration) Job {
+	return Job{
+		Name:  "expire-gates",
+		Every: time.Minute,
+		Run: func(ctx context.Context) error {
+			rs := rules.Current()
+			var longestPause time.Duration
+			for r := range rs.MaxPauseMinutes {
+				longestPause = max(longestPause, battle.MaxPause(rs, r))
+			}
+			cutoff := time.Now().Add(-(after + longestPause))
+			for {
+				runs, err := store.ExpireOverdueRuns(ctx, db, cutoff, expireBatch)
+				if err != nil {
+					return err
+				}
+				if len(runs) > 0 {
+					log.Printf("expired %d gates", len(runs))
+				}
+				if len(runs) < expireBatch {
+					return nil
+				}
+			}
+		},
+	}
+}
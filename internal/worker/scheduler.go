@@ -0,0 +1,93 @@
+package worker
+
+import (
+	"context"
+	"hash/fnv"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Job é uma tarefa periódica. Com várias réplicas rodando, cada execução pega um advisory lock do
+// Postgres pelo Name: quem não conseguir o lock pula aquela rodada.
+type Job struct {
+	Name  string
+	Every time.Duration
+	Run   func(ctx context.Context) error
+}
+
+// Scheduler roda os jobs dentro do próprio processo da API.
+type Scheduler struct {
+	db   *pgxpool.Pool
+	jobs []Job
+	wg   sync.WaitGroup
+}
+
+func NewScheduler(db *pgxpool.Pool) *Scheduler {
+	return &Scheduler{db: db}
+}
+
+func (s *Scheduler) Add(j Job) {
+	s.jobs = append(s.jobs, j)
+}
+
+// Start põe cada job pra rodar no seu intervalo até ctx ser cancelado. Não bloqueia; Wait espera as
+// execuções em andamento terminarem.
+func (s *Scheduler) Start(ctx context.Context) {
+	for _, j := range s.jobs {
+		s.wg.Add(1)
+		go func(j Job) {
+			defer s.wg.Done()
+			t := time.NewTicker(j.Every)
+			defer t.Stop()
+			for {
+				s.runOnce(ctx, j)
+				select {
+				case <-ctx.Done():
+					return
+				case <-t.C:
+				}
+			}
+		}(j)
+	}
+}
+
+func (s *Scheduler) Wait() {
+	s.wg.Wait()
+}
+
+func (s *Scheduler) runOnce(ctx context.Context, j Job) {
+	conn, err := s.db.Acquire(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Println("worker "+j.Name+": ", err)
+		}
+		return
+	}
+	defer conn.Release()
+
+	// lock de sessão: fica preso nessa conexão até o unlock (ou até ela cair)
+	key := lockKey(j.Name)
+	var locked bool
+	if err := conn.QueryRow(ctx, `SELECT pg_try_advisory_lock($1)`, key).Scan(&locked); err != nil {
+		log.Println("worker "+j.Name+": ", err)
+		return
+	}
+	if !locked {
+		return
+	}
+	defer conn.Exec(context.Background(), `SELECT pg_advisory_unlock($1)`, key)
+
+	if err := j.Run(ctx); err != nil && ctx.Err() == nil {
+		log.Println("worker "+j.Name+": ", err)
+	}
+}
+
+// lockKey transforma o nome do job na chave bigint do advisory lock.
+func lockKey(name string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte("solo-leveling/worker/" + name))
+	return int64(h.Sum64())
+}
@@ -0,0 +1,4 @@
+-- exclusão de conta agendada: até delete_after dá pra restaurar, depois o purge apaga de vez
+ALTER TABLE users ADD COLUMN IF NOT EXISTS delete_after TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_users_delete_after ON users(delete_after) WHERE delete_after IS NOT NULL;
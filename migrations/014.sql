@@ -0,0 +1,5 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS stat_points INT NOT NULL DEFAULT 0;
+ALTER TABLE level_up_events ADD COLUMN IF NOT EXISTS stat_points INT NOT NULL DEFAULT 0;
+
+-- quem já subiu de nível antes dos pontos existirem recebe o que teria ganho (3 por nível, o padrão)
+UPDATE users SET stat_points = (level - 1) * 3 WHERE level > 1 AND stat_points = 0;
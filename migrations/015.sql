@@ -0,0 +1,4 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS hunter_rank TEXT NOT NULL DEFAULT 'E';
+
+-- normal ou reassessment (gate de reavaliação que sobe o hunter de rank)
+ALTER TABLE focus_runs ADD COLUMN IF NOT EXISTS kind TEXT NOT NULL DEFAULT 'normal';
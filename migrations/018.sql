@@ -0,0 +1,37 @@
+CREATE TABLE IF NOT EXISTS items (
+    id TEXT PRIMARY KEY,
+    name TEXT NOT NULL,
+    description TEXT NOT NULL,
+    rarity TEXT NOT NULL,
+    effect TEXT NOT NULL CHECK (effect IN ('xp_multiplier', 'streak_shield', 'rank_key')),
+    power DOUBLE PRECISION NOT NULL
+);
+
+-- power: multiplicador de XP da poção; quantos tiers a chave abre acima do rank; o escudo não usa
+INSERT INTO items(id, name, description, rarity, effect, power) VALUES
+    ('xp_potion', 'Poção de XP', 'O próximo gate concluído rende 50% a mais de XP.', 'common', 'xp_multiplier', 1.5),
+    ('streak_shield', 'Escudo de Streak', 'Protege o streak uma vez contra abandono ou dia perdido.', 'rare', 'streak_shield', 1),
+    ('rank_key', 'Chave de Rank', 'Abre um gate um rank acima do seu.', 'epic', 'rank_key', 1)
+ON CONFLICT (id) DO NOTHING;
+
+CREATE TABLE IF NOT EXISTS user_items (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    item_id TEXT NOT NULL REFERENCES items(id),
+    quantity INT NOT NULL CHECK (quantity > 0),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (user_id, item_id)
+);
+
+-- item usado vira efeito ativo até ser consumido por um gate
+CREATE TABLE IF NOT EXISTS user_effects (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    item_id TEXT NOT NULL REFERENCES items(id),
+    effect TEXT NOT NULL,
+    power DOUBLE PRECISION NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    consumed_at TIMESTAMPTZ,
+    consumed_run_id UUID REFERENCES focus_runs(id) ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_effects_active ON user_effects(user_id, effect) WHERE consumed_at IS NULL;
@@ -0,0 +1,22 @@
+CREATE TABLE IF NOT EXISTS shop_rewards (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    title TEXT NOT NULL,
+    description TEXT,
+    price BIGINT NOT NULL CHECK (price > 0),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_shop_rewards_user ON shop_rewards(user_id);
+
+-- title e price são copiados no resgate: o histórico não muda se a recompensa for editada ou apagada
+CREATE TABLE IF NOT EXISTS reward_redemptions (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    reward_id UUID REFERENCES shop_rewards(id) ON DELETE SET NULL,
+    title TEXT NOT NULL,
+    price BIGINT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_reward_redemptions_user ON reward_redemptions(user_id, created_at);
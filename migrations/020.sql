@@ -0,0 +1,41 @@
+-- cada movimentação (tx_id) tem a perna do usuário (account='user') e a contrapartida numa conta de
+-- sistema ('system:<reason>'); a soma das pernas de uma movimentação é sempre zero.
+-- users.xp/users.gold viram só o cache da soma das pernas 'user'.
+CREATE TABLE IF NOT EXISTS ledger_entries (
+    id BIGSERIAL PRIMARY KEY,
+    tx_id UUID NOT NULL,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    account TEXT NOT NULL,
+    currency TEXT NOT NULL CHECK (currency IN ('xp', 'gold')),
+    amount BIGINT NOT NULL CHECK (amount <> 0),
+    reason TEXT NOT NULL,
+    ref_id UUID,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_ledger_entries_user ON ledger_entries(user_id, account, created_at);
+CREATE INDEX IF NOT EXISTS idx_ledger_entries_tx ON ledger_entries(tx_id);
+
+-- append-only: correção é uma movimentação nova, nunca um UPDATE. DELETE só acontece pelo cascade do purge.
+CREATE OR REPLACE FUNCTION ledger_entries_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'ledger_entries is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS ledger_entries_no_update ON ledger_entries;
+CREATE TRIGGER ledger_entries_no_update BEFORE UPDATE ON ledger_entries
+    FOR EACH ROW EXECUTE FUNCTION ledger_entries_append_only();
+
+-- saldo de abertura: o que cada usuário já tem entra como uma movimentação, pra ledger e cache baterem
+CREATE TEMP TABLE opening ON COMMIT DROP AS
+SELECT id AS user_id, gen_random_uuid() AS tx_id, xp, gold FROM users;
+
+INSERT INTO ledger_entries(tx_id, user_id, account, currency, amount, reason)
+SELECT tx_id, user_id, 'user', 'xp', xp, 'opening_balance' FROM opening WHERE xp <> 0
+UNION ALL
+SELECT tx_id, user_id, 'system:opening_balance', 'xp', -xp, 'opening_balance' FROM opening WHERE xp <> 0
+UNION ALL
+SELECT tx_id, user_id, 'user', 'gold', gold, 'opening_balance' FROM opening WHERE gold <> 0
+UNION ALL
+SELECT tx_id, user_id, 'system:opening_balance', 'gold', -gold, 'opening_balance' FROM opening WHERE gold <> 0;
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS focus_run_pauses (
+    id UUID PRIMARY KEY,
+    run_id UUID NOT NULL REFERENCES focus_runs(id) ON DELETE CASCADE,
+    started_at TIMESTAMPTZ NOT NULL,
+    ended_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_focus_run_pauses_run ON focus_run_pauses(run_id, started_at);
+-- no máximo uma pausa aberta por gate
+CREATE UNIQUE INDEX IF NOT EXISTS idx_focus_run_pauses_open ON focus_run_pauses(run_id) WHERE ended_at IS NULL;
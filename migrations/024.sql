@@ -0,0 +1,6 @@
+-- conteúdo de cada versão do ruleset, pra focus_runs.ruleset_version apontar pra números que não mudam
+CREATE TABLE IF NOT EXISTS rulesets (
+    version TEXT PRIMARY KEY,
+    body JSONB NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
@@ -0,0 +1,15 @@
+-- um gate aberto por hunter, senão dá pra abrir vários ao mesmo tempo e somar recompensa.
+-- Se já houver mais de um, os mais antigos fecham como expirados, sem recompensa.
+WITH ranked AS (
+    SELECT id, row_number() OVER (PARTITION BY user_id ORDER BY start_at DESC, id) AS n
+    FROM focus_runs WHERE end_at IS NULL
+)
+UPDATE focus_runs f SET end_at = now(), result = 'expired', xp_earned = 0, gold_earned = 0
+FROM ranked r
+WHERE f.id = r.id AND r.n > 1;
+
+UPDATE focus_run_pauses p SET ended_at = f.end_at
+FROM focus_runs f
+WHERE p.run_id = f.id AND p.ended_at IS NULL AND f.end_at IS NOT NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_focus_runs_open_user ON focus_runs(user_id) WHERE end_at IS NULL;